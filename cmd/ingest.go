@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+	"vet-tails/ai/internal/config"
+	"vet-tails/ai/internal/services"
+)
+
+// runIngest implements `ingest [-collection name] [-json] <dir|archive.zip>`.
+func runIngest(args []string) int {
+	fs := flag.NewFlagSet("ingest", flag.ContinueOnError)
+	collection := fs.String("collection", "vet_knowledge_base", "collection to ingest into")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: ingest [-collection name] [-json] <directory|archive.zip>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	target := fs.Arg(0)
+
+	cfg := config.LoadConfig()
+	cache, err := services.NewEmbeddingCache(cfg.EmbeddingCacheDir, cfg.EmbeddingCacheSize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ingest failed: %v\n", err)
+		return 1
+	}
+	embedder, err := services.NewEmbedder(cfg.OllamaURL, cfg.EmbeddingModel, cfg.EmbeddingDimension, cache)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ingest failed: %v\n", err)
+		return 1
+	}
+	store, err := services.NewVectorStore(cfg.VectorStore, cfg.ChromaURL, cfg.LocalVectorDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ingest failed: %v\n", err)
+		return 1
+	}
+	kb := services.NewKnowledgeBaseService(store, embedder, func(model string, dimension int) (services.Embedder, error) {
+		return services.NewEmbedder(cfg.OllamaURL, model, dimension, cache)
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var report *services.IngestReport
+	if strings.EqualFold(filepath.Ext(target), ".zip") {
+		report, err = kb.IngestZip(ctx, *collection, target)
+	} else {
+		report, err = kb.IngestDirectory(ctx, *collection, target)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ingest failed: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printIngestReport(report)
+		stats := cache.Stats()
+		fmt.Printf("embedding cache: %d hits, %d misses, %d evictions\n", stats.Hits, stats.Misses, stats.Evictions)
+	}
+
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printIngestReport(r *services.IngestReport) {
+	for _, f := range r.Files {
+		line := fmt.Sprintf("%-12s %s", f.Status, f.Source)
+		if f.Chunks > 0 {
+			line += fmt.Sprintf(" (%d chunks)", f.Chunks)
+		}
+		if f.Error != "" {
+			line += ": " + f.Error
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("\ncollection %s: %d added, %d updated, %d skipped, %d unsupported, %d failed, %d chunks in %s\n",
+		r.Collection, r.Added, r.Updated, r.Skipped, r.Unsupported, r.Failed, r.Chunks,
+		r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond))
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"log"
+	"os"
 	"vet-tails/ai/internal/router"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "ingest" {
+		os.Exit(runIngest(os.Args[2:]))
+	}
+
 	// Setup router
 	router := router.SetupRouter()
 
@@ -2,18 +2,58 @@ package config
 
 import (
 	"os"
+	"strconv"
 )
 
 type Config struct {
-	DatabaseURL string
-	OllamaURL   string
-	Port        string
+	DatabaseURL        string
+	OllamaURL          string
+	OllamaModel        string
+	ModelContextTokens int
+	Port               string
+	IngestRoot         string
+	MaxUploadSize      int64
+	MaxImageDimension  int
+	EmbeddingModel     string
+	EmbeddingDimension int
+	EmbeddingCacheDir  string
+	EmbeddingCacheSize int
+	VectorStore        string
+	ChromaURL          string
+	LocalVectorDir     string
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		DatabaseURL: os.Getenv("DATABASE_URL"),
-		OllamaURL:   os.Getenv("OLLAMA_URL"),
-		Port:        os.Getenv("PORT"),
+		DatabaseURL:        os.Getenv("DATABASE_URL"),
+		OllamaURL:          getEnv("OLLAMA_URL", "http://localhost:11434"),
+		OllamaModel:        getEnv("OLLAMA_MODEL", "mistral"),
+		ModelContextTokens: int(getEnvInt64("MODEL_CONTEXT_TOKENS", 8192)),
+		Port:               os.Getenv("PORT"),
+		IngestRoot:         os.Getenv("INGEST_ROOT"),
+		MaxUploadSize:      getEnvInt64("MAX_UPLOAD_SIZE", 20<<20),
+		MaxImageDimension:  int(getEnvInt64("MAX_IMAGE_DIMENSION", 1024)),
+		EmbeddingModel:     getEnv("EMBEDDING_MODEL", "nomic-embed-text"),
+		EmbeddingDimension: int(getEnvInt64("EMBEDDING_DIMENSION", 768)),
+		EmbeddingCacheDir:  getEnv("EMBEDDING_CACHE_DIR", ".cache/embeddings"),
+		EmbeddingCacheSize: int(getEnvInt64("EMBEDDING_CACHE_MAX_ENTRIES", 100000)),
+		VectorStore:        getEnv("VECTOR_STORE", "chroma"),
+		ChromaURL:          getEnv("CHROMA_URL", "http://localhost:8000"),
+		LocalVectorDir:     getEnv("LOCAL_VECTOR_STORE_DIR", ".data/vectors"),
 	}
 }
+
+func getEnv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvInt64(key string, fallback int64) int64 {
+	v, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return v
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"log"
+	"vet-tails/ai/internal/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -14,16 +15,45 @@ func InitDB(databaseURL string) *gorm.DB {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// // Auto-migrate the schemas
-	// err = db.AutoMigrate(
-	// 	&models.Patient{},
-	// 	&models.Allergy{},
-	// 	// &models.Note{},
-	// 	// &models.Breed{},
-	// )
-	// if err != nil {
-	// 	log.Fatalf("Failed to migrate database: %v", err)
-	// }
+	// Auto-migrate the schemas
+	err = db.AutoMigrate(
+		&models.Patient{},
+		&models.Allergy{},
+		&models.PatientAllergy{},
+		&models.Note{},
+		&models.NoteVersion{},
+		&models.PatientSummary{},
+		&models.VisitSummary{},
+		&models.HistoryDigest{},
+		&models.BodyConditionRecord{},
+		&models.ActivityLog{},
+		&models.FollowUpTask{},
+		&models.FollowUpTaskEvent{},
+		// &models.Breed{},
+	)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// Allergies recorded before severity moved onto patient_allergies take
+	// the allergy's shared severity and description
+	err = db.Exec(`UPDATE patient_allergies
+		SET severity = allergies.severity, description = allergies.description
+		FROM allergies
+		WHERE allergies.id = patient_allergies.allergy_id AND patient_allergies.severity IS NULL`).Error
+	if err != nil {
+		log.Fatalf("Failed to migrate patient allergies: %v", err)
+	}
+
+	// Summaries stored before they had their own patient_id were saved under
+	// the patient's ID, bypassing the ID sequence
+	err = db.Exec(`UPDATE patient_summaries SET patient_id = id WHERE patient_id IS NULL`).Error
+	if err == nil {
+		err = db.Exec(`SELECT setval(pg_get_serial_sequence('patient_summaries', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM patient_summaries`).Error
+	}
+	if err != nil {
+		log.Fatalf("Failed to migrate patient summaries: %v", err)
+	}
 
 	fmt.Println("Database connected and migrated successfully")
 	return db
@@ -0,0 +1,116 @@
+package formulary
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How a medication matched an allergy.
+const (
+	MatchExact         = "exact"
+	MatchClass         = "class"
+	MatchCrossReactive = "cross_reactive"
+)
+
+// Allergen is a recorded patient allergy.
+type Allergen struct {
+	Name     string
+	Severity string
+}
+
+// AllergyConflict is a planned medication that matches a recorded allergy.
+type AllergyConflict struct {
+	Medication string
+	Drug       string
+	Allergy    string
+	Severity   string
+	Match      string
+	// Class is the shared or cross-reactive drug class, if any.
+	Class    string
+	Blocking bool
+	Message  string
+}
+
+// severeAllergy reports whether a recorded severity warrants blocking even
+// on partial cross-reactivity.
+func severeAllergy(severity string) bool {
+	s := strings.ToLower(severity)
+	for _, word := range []string{"severe", "anaphyla", "life threatening", "high"} {
+		if strings.Contains(s, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAllergies cross-checks medications against the recorded allergies. An
+// allergy to a drug or to a class applies to every drug in that class (a
+// penicillin allergy covers amoxicillin). Exact and class matches block;
+// cross-reactive classes, such as penicillins and cephalosporins, block only
+// when the allergy is recorded as severe.
+func CheckAllergies(allergies []Allergen, medications []string) []AllergyConflict {
+	var conflicts []AllergyConflict
+	for _, medication := range medications {
+		for _, mention := range FindDrugs(medication) {
+			for _, allergy := range allergies {
+				if c, ok := matchAllergy(mention.Drug, allergy); ok {
+					c.Medication = medication
+					conflicts = append(conflicts, c)
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+func matchAllergy(drug *Drug, allergy Allergen) (AllergyConflict, bool) {
+	conflict := AllergyConflict{
+		Drug:     drug.Name,
+		Allergy:  allergy.Name,
+		Severity: allergy.Severity,
+	}
+
+	var allergyClasses []string
+	for _, c := range FindClasses(allergy.Name) {
+		allergyClasses = append(allergyClasses, c.Name)
+	}
+	for _, m := range FindDrugs(allergy.Name) {
+		if m.Drug == drug {
+			conflict.Match = MatchExact
+			conflict.Blocking = true
+			conflict.Message = fmt.Sprintf("%s is recorded as an allergy", drug.Name)
+			return conflict, true
+		}
+		for _, c := range m.Drug.Classes {
+			if class, ok := classByName[c]; ok && class.Allergenic {
+				allergyClasses = append(allergyClasses, c)
+			}
+		}
+	}
+
+	for _, c := range allergyClasses {
+		if drug.HasClass(c) {
+			class := classByName[c]
+			conflict.Match = MatchClass
+			conflict.Class = c
+			conflict.Blocking = true
+			conflict.Message = fmt.Sprintf("%s belongs to the %s class; patient is allergic to %s", drug.Name, class.Label, allergy.Name)
+			return conflict, true
+		}
+	}
+
+	for _, c := range allergyClasses {
+		class := classByName[c]
+		for _, related := range class.CrossReactive {
+			if !drug.HasClass(related) {
+				continue
+			}
+			conflict.Match = MatchCrossReactive
+			conflict.Class = related
+			conflict.Blocking = severeAllergy(allergy.Severity)
+			conflict.Message = fmt.Sprintf("%s (%s) may cross-react with the recorded %s allergy (%s)", drug.Name, classByName[related].Label, allergy.Name, class.Label)
+			return conflict, true
+		}
+	}
+	return conflict, false
+}
@@ -0,0 +1,45 @@
+package formulary
+
+import "testing"
+
+func TestCheckAllergies(t *testing.T) {
+	tests := []struct {
+		name       string
+		allergy    Allergen
+		medication string
+		wantMatch  string
+		blocking   bool
+	}{
+		{"penicillin covers amoxicillin", Allergen{Name: "penicillin"}, "Amoxicillin 250 mg PO BID", MatchClass, true},
+		{"penicillin covers clavamox", Allergen{Name: "Penicillins"}, "Clavamox 62.5 mg PO BID", MatchClass, true},
+		{"drug allergy covers its class", Allergen{Name: "amoxicillin"}, "ampicillin 20 mg/kg IV", MatchClass, true},
+		{"exact drug", Allergen{Name: "amoxicillin"}, "amoxicillin 20 mg/kg PO BID", MatchExact, true},
+		{"brand name", Allergen{Name: "Rimadyl"}, "carprofen 2.2 mg/kg PO BID", MatchExact, true},
+		{"NSAID class", Allergen{Name: "NSAIDs"}, "meloxicam 0.1 mg/kg PO SID", MatchClass, true},
+		{"mild cross-reactivity warns", Allergen{Name: "penicillin", Severity: "mild rash"}, "cephalexin 22 mg/kg PO BID", MatchCrossReactive, false},
+		{"severe cross-reactivity blocks", Allergen{Name: "penicillin", Severity: "anaphylaxis"}, "cephalexin 22 mg/kg PO BID", MatchCrossReactive, true},
+		{"unrelated drug", Allergen{Name: "penicillin"}, "meloxicam 0.1 mg/kg PO SID", "", false},
+		{"non-drug allergen", Allergen{Name: "chicken"}, "amoxicillin 20 mg/kg PO BID", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := CheckAllergies([]Allergen{tt.allergy}, []string{tt.medication})
+			if tt.wantMatch == "" {
+				if len(conflicts) != 0 {
+					t.Errorf("conflicts = %+v, want none", conflicts)
+				}
+				return
+			}
+			if len(conflicts) != 1 {
+				t.Fatalf("conflicts = %+v, want one", conflicts)
+			}
+			c := conflicts[0]
+			if c.Match != tt.wantMatch || c.Blocking != tt.blocking {
+				t.Errorf("match = %s, blocking = %v; want %s, %v", c.Match, c.Blocking, tt.wantMatch, tt.blocking)
+			}
+			if c.Medication != tt.medication || c.Allergy != tt.allergy.Name {
+				t.Errorf("conflict = %+v", c)
+			}
+		})
+	}
+}
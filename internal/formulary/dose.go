@@ -0,0 +1,236 @@
+package formulary
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownDrug     = errors.New("no dosing data for this drug")
+	ErrNoSpeciesDose   = errors.New("no dose is listed for this species")
+	ErrInvalidWeight   = errors.New("weight must be a positive number of kilograms")
+	ErrUnconvertedDose = errors.New("dose cannot be converted to mg")
+)
+
+// Dose check results.
+const (
+	DoseWithinRange     = "within_range"
+	DoseBelowRange      = "below_range"
+	DoseAboveRange      = "above_range"
+	DoseAboveMax        = "above_max_dose"
+	DoseContraindicated = "contraindicated"
+	DoseNotChecked      = "not_checked"
+)
+
+// DoseRequest describes a dose to calculate and, when Dose is set, a
+// prescribed dose to check.
+type DoseRequest struct {
+	Drug     string
+	Species  string
+	WeightKg float64
+	// Dose and Unit are the prescribed dose, e.g. 12.5 "mg/kg", 250 "mg" or
+	// 1 "tablet". Tablet, capsule and ml doses need Strength.
+	Dose      float64
+	Unit      string
+	Strength  string
+	Frequency string
+}
+
+// DoseResult is the computed safe range for a patient and, when a dose was
+// given, how it compares.
+type DoseResult struct {
+	Drug       string  `json:"drug"`
+	Species    string  `json:"species"`
+	WeightKg   float64 `json:"weight_kg"`
+	MinMgPerKg float64 `json:"min_mg_per_kg,omitempty"`
+	MaxMgPerKg float64 `json:"max_mg_per_kg,omitempty"`
+	// MinDoseMg and MaxDoseMg are the range for this patient's weight, with
+	// MaxDoseMg capped at the drug's maximum single dose.
+	MinDoseMg  float64 `json:"min_dose_mg,omitempty"`
+	MaxDoseMg  float64 `json:"max_dose_mg,omitempty"`
+	MaxDailyMg float64 `json:"max_daily_mg,omitempty"`
+	Frequency  string  `json:"frequency,omitempty"`
+	Note       string  `json:"note,omitempty"`
+
+	PrescribedMg      float64 `json:"prescribed_mg,omitempty"`
+	PrescribedMgPerKg float64 `json:"prescribed_mg_per_kg,omitempty"`
+	Status            string  `json:"status"`
+	Contraindicated   bool    `json:"contraindicated"`
+	Message           string  `json:"message"`
+}
+
+// NormalizeSpecies maps "canine", "Dog" and the like to the formulary's
+// species names.
+func NormalizeSpecies(species string) string {
+	s := normalize(species)
+	switch s {
+	case "dog", "dogs", "canine", "k9", "puppy":
+		return SpeciesDog
+	case "cat", "cats", "feline", "kitten":
+		return SpeciesCat
+	}
+	return s
+}
+
+// SafeRange renders the result's range, e.g. "10–20 mg/kg (120–240 mg)".
+func (r *DoseResult) SafeRange() string {
+	if r.MaxMgPerKg == 0 {
+		return ""
+	}
+	perKg := formatRange(r.MinMgPerKg, r.MaxMgPerKg) + " mg/kg"
+	if r.MaxDoseMg == 0 {
+		return perKg
+	}
+	return fmt.Sprintf("%s (%s mg for %s kg)", perKg, formatRange(r.MinDoseMg, r.MaxDoseMg), formatNumber(r.WeightKg))
+}
+
+// CalculateDose computes the safe dose range for a patient and checks the
+// prescribed dose against it. Contraindications are reported as a result
+// rather than an error so callers can surface them.
+func CalculateDose(req DoseRequest) (*DoseResult, error) {
+	drug, ok := Lookup(req.Drug)
+	if !ok {
+		return nil, ErrUnknownDrug
+	}
+	species := NormalizeSpecies(req.Species)
+	result := &DoseResult{Drug: drug.Name, Species: species, WeightKg: req.WeightKg, Status: DoseNotChecked}
+
+	guide, ok := doseGuides[drug.Name]
+	if !ok {
+		return nil, ErrUnknownDrug
+	}
+	for _, c := range guide.Contraindications {
+		if c.Species == species {
+			result.Status = DoseContraindicated
+			result.Contraindicated = true
+			result.Message = fmt.Sprintf("%s is contraindicated in %ss: %s", drug.Name, species, c.Reason)
+			return result, nil
+		}
+	}
+
+	var dr *DoseRange
+	for i := range guide.Ranges {
+		if guide.Ranges[i].Species == species {
+			dr = &guide.Ranges[i]
+			break
+		}
+	}
+	if dr == nil {
+		return nil, ErrNoSpeciesDose
+	}
+	if req.WeightKg <= 0 || math.IsNaN(req.WeightKg) || math.IsInf(req.WeightKg, 0) {
+		return nil, ErrInvalidWeight
+	}
+
+	result.MinMgPerKg, result.MaxMgPerKg = dr.MinMgPerKg, dr.MaxMgPerKg
+	result.MinDoseMg = round(dr.MinMgPerKg * req.WeightKg)
+	result.MaxDoseMg = round(dr.MaxMgPerKg * req.WeightKg)
+	if dr.MaxDoseMg > 0 && result.MaxDoseMg > dr.MaxDoseMg {
+		result.MaxDoseMg = dr.MaxDoseMg
+		result.MinDoseMg = math.Min(result.MinDoseMg, dr.MaxDoseMg)
+	}
+	if dr.MaxDailyMgPerKg > 0 {
+		result.MaxDailyMg = round(dr.MaxDailyMgPerKg * req.WeightKg)
+	}
+	result.Frequency = dr.Frequency
+	result.Note = dr.Note
+	result.Message = fmt.Sprintf("%s for a %s kg %s: %s", drug.Name, formatNumber(req.WeightKg), species, result.SafeRange())
+
+	if req.Dose <= 0 {
+		return result, nil
+	}
+	mg, err := doseInMg(req.Dose, req.Unit, req.Strength, req.WeightKg)
+	if err != nil {
+		return result, err
+	}
+	result.PrescribedMg = round(mg)
+	result.PrescribedMgPerKg = round(mg / req.WeightKg)
+
+	// A small tolerance keeps rounding to tablet sizes from being flagged.
+	const tolerance = 1.05
+	switch {
+	case dr.MaxDoseMg > 0 && mg > dr.MaxDoseMg*tolerance:
+		result.Status = DoseAboveMax
+		result.Message = fmt.Sprintf("%s mg exceeds the maximum single dose of %s mg", formatNumber(result.PrescribedMg), formatNumber(dr.MaxDoseMg))
+	case result.MaxDailyMg > 0 && DosesPerDay(NormalizeFrequency(req.Frequency))*mg > result.MaxDailyMg*tolerance:
+		result.Status = DoseAboveMax
+		result.Message = fmt.Sprintf("%s mg %s exceeds the maximum daily dose of %s mg", formatNumber(result.PrescribedMg), NormalizeFrequency(req.Frequency), formatNumber(result.MaxDailyMg))
+	case mg > result.MaxDoseMg*tolerance:
+		result.Status = DoseAboveRange
+		result.Message = fmt.Sprintf("%s mg (%s mg/kg) is above the usual range of %s", formatNumber(result.PrescribedMg), formatNumber(result.PrescribedMgPerKg), result.SafeRange())
+	case mg < result.MinDoseMg/tolerance:
+		result.Status = DoseBelowRange
+		result.Message = fmt.Sprintf("%s mg (%s mg/kg) is below the usual range of %s", formatNumber(result.PrescribedMg), formatNumber(result.PrescribedMgPerKg), result.SafeRange())
+	default:
+		result.Status = DoseWithinRange
+		result.Message = fmt.Sprintf("%s mg (%s mg/kg) is within %s", formatNumber(result.PrescribedMg), formatNumber(result.PrescribedMgPerKg), result.SafeRange())
+	}
+	return result, nil
+}
+
+// doseInMg converts a prescribed dose to milligrams for this patient.
+func doseInMg(dose float64, unit, strength string, weightKg float64) (float64, error) {
+	unit = NormalizeUnit(unit)
+	if base, ok := strings.CutSuffix(unit, "/kg"); ok {
+		mg, err := doseInMg(dose, base, strength, weightKg)
+		return mg * weightKg, err
+	}
+	switch unit {
+	case "mg":
+		return dose, nil
+	case "mcg":
+		return dose / 1000, nil
+	case "g":
+		return dose * 1000, nil
+	case "tablet", "capsule", "chew", "ml":
+		perUnit, ok := strengthInMg(strength, unit)
+		if !ok {
+			return 0, ErrUnconvertedDose
+		}
+		return dose * perUnit, nil
+	}
+	return 0, ErrUnconvertedDose
+}
+
+var strengthPattern = regexp.MustCompile(`(?i)^\s*(\d+(?:\.\d+)?)\s*(mg|mcg|ug|g)\b(?:\s*/\s*([a-z]+))?`)
+
+// strengthInMg reads a product strength such as "250 mg" (per tablet) or
+// "1.5 mg/ml" in mg per unit.
+func strengthInMg(strength, unit string) (float64, bool) {
+	m := strengthPattern.FindStringSubmatch(strength)
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	// A concentration only converts volume doses, and a plain strength only
+	// converts solid doses.
+	if (unit == "ml") != (NormalizeUnit(m[3]) == "ml") {
+		return 0, false
+	}
+	switch NormalizeUnit(m[2]) {
+	case "mcg":
+		return n / 1000, true
+	case "g":
+		return n * 1000, true
+	}
+	return n, true
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(round(v), 'f', -1, 64)
+}
+
+func formatRange(min, max float64) string {
+	if min == max {
+		return formatNumber(min)
+	}
+	return formatNumber(min) + "–" + formatNumber(max)
+}
@@ -0,0 +1,172 @@
+package formulary
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCalculateDose(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        DoseRequest
+		wantStatus string
+		wantMg     float64
+		wantErr    error
+	}{
+		{
+			name:       "permethrin in cats",
+			req:        DoseRequest{Drug: "permethrin", Species: "feline", WeightKg: 4},
+			wantStatus: DoseContraindicated,
+		},
+		{
+			name:    "permethrin in dogs has no dose",
+			req:     DoseRequest{Drug: "permethrin", Species: "dog", WeightKg: 20},
+			wantErr: ErrNoSpeciesDose,
+		},
+		{
+			name:       "range only",
+			req:        DoseRequest{Drug: "amoxicillin", Species: "dog", WeightKg: 10},
+			wantStatus: DoseNotChecked,
+		},
+		{
+			name:       "mg/kg within range",
+			req:        DoseRequest{Drug: "amoxicillin", Species: "canine", WeightKg: 10, Dose: 12.5, Unit: "mg/kg"},
+			wantStatus: DoseWithinRange,
+			wantMg:     125,
+		},
+		{
+			name:       "above range",
+			req:        DoseRequest{Drug: "amoxicillin", Species: "dog", WeightKg: 10, Dose: 500, Unit: "mg"},
+			wantStatus: DoseAboveRange,
+			wantMg:     500,
+		},
+		{
+			name:       "below range",
+			req:        DoseRequest{Drug: "amoxicillin", Species: "dog", WeightKg: 10, Dose: 50, Unit: "mg"},
+			wantStatus: DoseBelowRange,
+			wantMg:     50,
+		},
+		{
+			name:       "above max single dose",
+			req:        DoseRequest{Drug: "trazodone", Species: "dog", WeightKg: 50, Dose: 400, Unit: "mg"},
+			wantStatus: DoseAboveMax,
+			wantMg:     400,
+		},
+		{
+			name:       "at max single dose",
+			req:        DoseRequest{Drug: "trazodone", Species: "dog", WeightKg: 50, Dose: 300, Unit: "mg"},
+			wantStatus: DoseWithinRange,
+			wantMg:     300,
+		},
+		{
+			name:       "above max daily dose",
+			req:        DoseRequest{Drug: "meloxicam", Species: "dog", WeightKg: 10, Dose: 2, Unit: "mg", Frequency: "BID"},
+			wantStatus: DoseAboveMax,
+			wantMg:     2,
+		},
+		{
+			name:       "within max daily dose",
+			req:        DoseRequest{Drug: "meloxicam", Species: "dog", WeightKg: 10, Dose: 2, Unit: "mg", Frequency: "SID"},
+			wantStatus: DoseWithinRange,
+			wantMg:     2,
+		},
+		{
+			name:       "tablet strength",
+			req:        DoseRequest{Drug: "amoxicillin", Species: "dog", WeightKg: 10, Dose: 0.5, Unit: "tablets", Strength: "250 mg"},
+			wantStatus: DoseWithinRange,
+			wantMg:     125,
+		},
+		{
+			name:       "whole tablet above range",
+			req:        DoseRequest{Drug: "amoxicillin", Species: "dog", WeightKg: 10, Dose: 1, Unit: "tab", Strength: "250mg"},
+			wantStatus: DoseAboveRange,
+			wantMg:     250,
+		},
+		{
+			name:       "ml concentration",
+			req:        DoseRequest{Drug: "amoxicillin", Species: "cat", WeightKg: 4, Dose: 1, Unit: "ml", Strength: "50 mg/ml"},
+			wantStatus: DoseWithinRange,
+			wantMg:     50,
+		},
+		{
+			name:       "microgram strength",
+			req:        DoseRequest{Drug: "amoxicillin", Species: "cat", WeightKg: 4, Dose: 2, Unit: "ml", Strength: "25000 mcg/ml"},
+			wantStatus: DoseWithinRange,
+			wantMg:     50,
+		},
+		{
+			name:    "ml without concentration",
+			req:     DoseRequest{Drug: "amoxicillin", Species: "dog", WeightKg: 10, Dose: 1, Unit: "ml", Strength: "250 mg"},
+			wantErr: ErrUnconvertedDose,
+		},
+		{
+			name:    "tablet with concentration",
+			req:     DoseRequest{Drug: "amoxicillin", Species: "dog", WeightKg: 10, Dose: 1, Unit: "tablet", Strength: "50 mg/ml"},
+			wantErr: ErrUnconvertedDose,
+		},
+		{
+			name:    "unknown drug",
+			req:     DoseRequest{Drug: "unobtainium", Species: "dog", WeightKg: 10},
+			wantErr: ErrUnknownDrug,
+		},
+		{
+			name:    "missing weight",
+			req:     DoseRequest{Drug: "amoxicillin", Species: "dog"},
+			wantErr: ErrInvalidWeight,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateDose(tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %s (%s), want %s", got.Status, got.Message, tt.wantStatus)
+			}
+			if got.PrescribedMg != tt.wantMg {
+				t.Errorf("prescribed = %v mg, want %v", got.PrescribedMg, tt.wantMg)
+			}
+			if got.Contraindicated != (tt.wantStatus == DoseContraindicated) {
+				t.Errorf("contraindicated = %v", got.Contraindicated)
+			}
+		})
+	}
+}
+
+func TestCalculateDoseRange(t *testing.T) {
+	got, err := CalculateDose(DoseRequest{Drug: "trazodone", Species: "dog", WeightKg: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2–10 mg/kg is 100–500 mg, capped at the 300 mg maximum single dose.
+	if got.MinDoseMg != 100 || got.MaxDoseMg != 300 {
+		t.Errorf("range = %v–%v mg, want 100–300", got.MinDoseMg, got.MaxDoseMg)
+	}
+
+	got, err = CalculateDose(DoseRequest{Drug: "meloxicam", Species: "dog", WeightKg: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxDailyMg != 2 {
+		t.Errorf("max daily = %v mg, want 2", got.MaxDailyMg)
+	}
+}
+
+func TestNormalizeSpecies(t *testing.T) {
+	tests := map[string]string{
+		"Canine": SpeciesDog,
+		"puppy":  SpeciesDog,
+		"kitten": SpeciesCat,
+		"Feline": SpeciesCat,
+		"rabbit": "rabbit",
+	}
+	for in, want := range tests {
+		if got := NormalizeSpecies(in); got != want {
+			t.Errorf("NormalizeSpecies(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package formulary
+
+// Species with dosing data.
+const (
+	SpeciesDog = "dog"
+	SpeciesCat = "cat"
+)
+
+// DoseRange is the usual dose of a drug for one species, in mg/kg per dose.
+type DoseRange struct {
+	Species    string
+	MinMgPerKg float64
+	MaxMgPerKg float64
+	// MaxDoseMg caps a single dose regardless of weight; 0 means no cap.
+	MaxDoseMg float64
+	// MaxDailyMgPerKg caps the total daily dose; 0 means no cap.
+	MaxDailyMgPerKg float64
+	Frequency       string
+	Note            string
+}
+
+// Contraindication marks a drug that must not be used in a species.
+type Contraindication struct {
+	Species string
+	Reason  string
+}
+
+// DoseGuide is the dosing data held for a drug.
+type DoseGuide struct {
+	Drug              string
+	Ranges            []DoseRange
+	Contraindications []Contraindication
+}
+
+var doseGuides = map[string]DoseGuide{}
+
+func init() {
+	for _, g := range guides {
+		doseGuides[g.Drug] = g
+	}
+}
+
+func dog(min, max float64, frequency string) DoseRange {
+	return DoseRange{Species: SpeciesDog, MinMgPerKg: min, MaxMgPerKg: max, Frequency: frequency}
+}
+
+func cat(min, max float64, frequency string) DoseRange {
+	return DoseRange{Species: SpeciesCat, MinMgPerKg: min, MaxMgPerKg: max, Frequency: frequency}
+}
+
+func withCap(r DoseRange, maxDoseMg, maxDailyMgPerKg float64, note string) DoseRange {
+	r.MaxDoseMg = maxDoseMg
+	r.MaxDailyMgPerKg = maxDailyMgPerKg
+	r.Note = note
+	return r
+}
+
+// guides lists commonly used label and formulary doses. They are a safety
+// net for generated plans, not a substitute for a current drug reference.
+var guides = []DoseGuide{
+	// Antimicrobials
+	{Drug: "amoxicillin", Ranges: []DoseRange{dog(10, 20, FrequencyQ12h), cat(10, 20, FrequencyQ12h)}},
+	{Drug: "amoxicillin-clavulanate", Ranges: []DoseRange{dog(12.5, 25, FrequencyQ12h), cat(12.5, 25, FrequencyQ12h)}},
+	{Drug: "ampicillin", Ranges: []DoseRange{dog(10, 22, FrequencyQ8h), cat(10, 22, FrequencyQ8h)}},
+	{Drug: "cephalexin", Ranges: []DoseRange{dog(15, 30, FrequencyQ12h), cat(15, 30, FrequencyQ12h)}},
+	{Drug: "cefazolin", Ranges: []DoseRange{dog(20, 30, FrequencyQ8h), cat(20, 30, FrequencyQ8h)}},
+	{Drug: "cefpodoxime", Ranges: []DoseRange{dog(5, 10, FrequencyQ24h), cat(5, 10, FrequencyQ24h)}},
+	{Drug: "cefovecin", Ranges: []DoseRange{
+		withCap(dog(8, 8, "every 14 days"), 0, 0, "single injection lasting 14 days"),
+		withCap(cat(8, 8, "every 14 days"), 0, 0, "single injection lasting 14 days"),
+	}},
+	{Drug: "enrofloxacin", Ranges: []DoseRange{
+		dog(5, 20, FrequencyQ24h),
+		withCap(cat(5, 5, FrequencyQ24h), 0, 5, "higher doses cause retinal degeneration and blindness in cats"),
+	}},
+	{Drug: "marbofloxacin", Ranges: []DoseRange{dog(2.75, 5.5, FrequencyQ24h), cat(2.75, 5.5, FrequencyQ24h)}},
+	{Drug: "pradofloxacin", Ranges: []DoseRange{dog(3, 3, FrequencyQ24h), cat(5, 7.5, FrequencyQ24h)}},
+	{Drug: "doxycycline", Ranges: []DoseRange{dog(5, 10, FrequencyQ12h), withCap(cat(5, 10, FrequencyQ12h), 0, 0, "follow tablets with water to prevent esophageal stricture")}},
+	{Drug: "clindamycin", Ranges: []DoseRange{dog(5.5, 11, FrequencyQ12h), cat(5.5, 11, FrequencyQ12h)}},
+	{Drug: "metronidazole", Ranges: []DoseRange{
+		withCap(dog(10, 15, FrequencyQ12h), 0, 50, "neurotoxicity above 50 mg/kg/day"),
+		withCap(cat(10, 15, FrequencyQ12h), 0, 50, "neurotoxicity above 50 mg/kg/day"),
+	}},
+	{Drug: "trimethoprim-sulfamethoxazole", Ranges: []DoseRange{dog(15, 30, FrequencyQ12h), cat(15, 30, FrequencyQ12h)}},
+	{Drug: "trimethoprim-sulfadiazine", Ranges: []DoseRange{dog(15, 30, FrequencyQ12h), cat(15, 30, FrequencyQ12h)}},
+	{Drug: "azithromycin", Ranges: []DoseRange{dog(5, 10, FrequencyQ24h), cat(5, 10, FrequencyQ24h)}},
+
+	// Analgesics and anti-inflammatories
+	{Drug: "meloxicam", Ranges: []DoseRange{
+		withCap(dog(0.1, 0.2, FrequencyQ24h), 0, 0.2, "0.2 mg/kg loading dose, then 0.1 mg/kg"),
+		withCap(cat(0.025, 0.1, FrequencyQ24h), 0, 0.1, "long-term use only at the lowest effective dose"),
+	}},
+	{Drug: "carprofen", Ranges: []DoseRange{withCap(dog(2.2, 4.4, FrequencyQ12h), 0, 4.4, "2.2 mg/kg q12h or 4.4 mg/kg q24h")}},
+	{Drug: "robenacoxib", Ranges: []DoseRange{dog(1, 2, FrequencyQ24h), cat(1, 2.4, FrequencyQ24h)}},
+	{Drug: "firocoxib", Ranges: []DoseRange{dog(5, 5, FrequencyQ24h)}},
+	{Drug: "deracoxib", Ranges: []DoseRange{dog(1, 4, FrequencyQ24h)}},
+	{Drug: "grapiprant", Ranges: []DoseRange{dog(2, 2, FrequencyQ24h)}},
+	{Drug: "gabapentin", Ranges: []DoseRange{dog(5, 20, FrequencyQ8h), cat(5, 20, FrequencyQ12h)}},
+	{Drug: "tramadol", Ranges: []DoseRange{dog(2, 5, FrequencyQ8h), cat(1, 4, FrequencyQ12h)}},
+	{Drug: "buprenorphine", Ranges: []DoseRange{dog(0.01, 0.02, FrequencyQ8h), cat(0.01, 0.03, FrequencyQ8h)}},
+	{Drug: "butorphanol", Ranges: []DoseRange{dog(0.2, 0.4, FrequencyQ6h), cat(0.2, 0.4, FrequencyQ6h)}},
+	{Drug: "methadone", Ranges: []DoseRange{dog(0.1, 0.5, FrequencyQ6h), cat(0.1, 0.3, FrequencyQ6h)}},
+	{Drug: "prednisolone", Ranges: []DoseRange{dog(0.25, 2, FrequencyQ24h), cat(0.5, 4, FrequencyQ24h)}},
+	{Drug: "prednisone", Ranges: []DoseRange{dog(0.25, 2, FrequencyQ24h)}},
+	{Drug: "dexamethasone", Ranges: []DoseRange{dog(0.05, 0.2, FrequencyQ24h), cat(0.05, 0.2, FrequencyQ24h)}},
+
+	// Gastrointestinal
+	{Drug: "maropitant", Ranges: []DoseRange{
+		withCap(dog(1, 2, FrequencyQ24h), 0, 0, "1 mg/kg injectable or 2 mg/kg oral; 8 mg/kg oral for motion sickness"),
+		cat(1, 1, FrequencyQ24h),
+	}},
+	{Drug: "ondansetron", Ranges: []DoseRange{dog(0.1, 1, FrequencyQ12h), cat(0.1, 1, FrequencyQ12h)}},
+	{Drug: "metoclopramide", Ranges: []DoseRange{dog(0.2, 0.5, FrequencyQ8h), cat(0.2, 0.5, FrequencyQ8h)}},
+	{Drug: "famotidine", Ranges: []DoseRange{dog(0.5, 1, FrequencyQ12h), cat(0.5, 1, FrequencyQ12h)}},
+	{Drug: "omeprazole", Ranges: []DoseRange{dog(0.5, 1, FrequencyQ12h), cat(0.5, 1, FrequencyQ12h)}},
+	{Drug: "sucralfate", Ranges: []DoseRange{withCap(dog(20, 40, FrequencyQ8h), 1000, 0, ""), withCap(cat(20, 40, FrequencyQ8h), 250, 0, "")}},
+
+	// Cardiovascular and renal
+	{Drug: "furosemide", Ranges: []DoseRange{dog(1, 4, FrequencyQ12h), cat(1, 2, FrequencyQ12h)}},
+	{Drug: "enalapril", Ranges: []DoseRange{dog(0.25, 0.5, FrequencyQ12h), cat(0.25, 0.5, FrequencyQ24h)}},
+	{Drug: "benazepril", Ranges: []DoseRange{dog(0.25, 0.5, FrequencyQ24h), cat(0.5, 1, FrequencyQ24h)}},
+	{Drug: "pimobendan", Ranges: []DoseRange{dog(0.2, 0.3, FrequencyQ12h)}},
+	{Drug: "amlodipine", Ranges: []DoseRange{dog(0.1, 0.5, FrequencyQ24h), cat(0.125, 0.25, FrequencyQ24h)}},
+
+	// Neurology and behavior
+	{Drug: "phenobarbital", Ranges: []DoseRange{dog(2, 3, FrequencyQ12h), cat(1, 2.5, FrequencyQ12h)}},
+	{Drug: "levetiracetam", Ranges: []DoseRange{dog(20, 30, FrequencyQ8h), cat(20, 30, FrequencyQ8h)}},
+	{Drug: "fluoxetine", Ranges: []DoseRange{dog(1, 2, FrequencyQ24h), cat(0.5, 1, FrequencyQ24h)}},
+	{Drug: "clomipramine", Ranges: []DoseRange{dog(1, 3, FrequencyQ12h), cat(0.25, 0.5, FrequencyQ24h)}},
+	{Drug: "trazodone", Ranges: []DoseRange{withCap(dog(2, 10, FrequencyQ12h), 300, 0, "")}},
+	{Drug: "acepromazine", Ranges: []DoseRange{withCap(dog(0.01, 0.05, FrequencyOnce), 3, 0, "injectable dose"), withCap(cat(0.01, 0.05, FrequencyOnce), 3, 0, "injectable dose")}},
+	{Drug: "dexmedetomidine", Ranges: []DoseRange{dog(0.001, 0.01, FrequencyOnce), cat(0.003, 0.04, FrequencyOnce)}},
+
+	// Dermatology and allergy
+	{Drug: "oclacitinib", Ranges: []DoseRange{withCap(dog(0.4, 0.6, FrequencyQ12h), 0, 0, "q12h for up to 14 days, then q24h")}},
+	{Drug: "diphenhydramine", Ranges: []DoseRange{dog(2, 4, FrequencyQ8h), cat(1, 2, FrequencyQ8h)}},
+	{Drug: "cetirizine", Ranges: []DoseRange{withCap(dog(1, 2, FrequencyQ24h), 0, 0, ""), withCap(cat(0.5, 1, FrequencyQ24h), 5, 0, "")}},
+	{Drug: "itraconazole", Ranges: []DoseRange{dog(5, 5, FrequencyQ24h), cat(5, 10, FrequencyQ24h)}},
+
+	// Drugs with species contraindications
+	{Drug: "permethrin", Contraindications: []Contraindication{{Species: SpeciesCat, Reason: "cats lack the glucuronidation needed to clear permethrin; exposure causes tremors, seizures and death"}}},
+	{Drug: "acetaminophen", Contraindications: []Contraindication{{Species: SpeciesCat, Reason: "causes methemoglobinemia and hepatic necrosis in cats at any dose"}}},
+	{Drug: "ibuprofen", Contraindications: []Contraindication{
+		{Species: SpeciesDog, Reason: "narrow safety margin; causes gastrointestinal ulceration and kidney injury"},
+		{Species: SpeciesCat, Reason: "causes gastrointestinal ulceration and kidney injury"},
+	}},
+	{Drug: "naproxen", Contraindications: []Contraindication{
+		{Species: SpeciesDog, Reason: "long half-life in dogs; causes gastrointestinal ulceration and kidney injury"},
+		{Species: SpeciesCat, Reason: "causes gastrointestinal ulceration and kidney injury"},
+	}},
+	{Drug: "amitraz", Contraindications: []Contraindication{{Species: SpeciesCat, Reason: "not safe for use in cats"}}},
+}
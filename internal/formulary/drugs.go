@@ -0,0 +1,233 @@
+package formulary
+
+// Drug class names.
+const (
+	ClassPenicillin      = "penicillin"
+	ClassCephalosporin   = "cephalosporin"
+	ClassCarbapenem      = "carbapenem"
+	ClassSulfonamide     = "sulfonamide"
+	ClassFluoroquinolone = "fluoroquinolone"
+	ClassTetracycline    = "tetracycline"
+	ClassMacrolide       = "macrolide"
+	ClassLincosamide     = "lincosamide"
+	ClassNitroimidazole  = "nitroimidazole"
+	ClassAminoglycoside  = "aminoglycoside"
+	ClassNSAID           = "nsaid"
+	ClassCorticosteroid  = "corticosteroid"
+	ClassOpioid          = "opioid"
+	ClassSerotonergic    = "serotonergic"
+	ClassBenzodiazepine  = "benzodiazepine"
+	ClassAlpha2Agonist   = "alpha2_agonist"
+	ClassAntihistamine   = "antihistamine"
+	ClassAzoleAntifungal = "azole_antifungal"
+	ClassMacrocyclicLact = "macrocyclic_lactone"
+	ClassIsoxazoline     = "isoxazoline"
+	ClassPyrethroid      = "pyrethroid"
+	ClassACEInhibitor    = "ace_inhibitor"
+	ClassLoopDiuretic    = "loop_diuretic"
+	ClassAnticonvulsant  = "anticonvulsant"
+	ClassAntiemetic      = "antiemetic"
+	ClassGastroprotect   = "gastroprotectant"
+	ClassLocalAnesthetic = "local_anesthetic"
+	ClassVaccine         = "vaccine"
+)
+
+var classes = []Class{
+	{Name: ClassPenicillin, Label: "penicillins", Aliases: []string{"beta lactam", "beta lactams", "penicillin class"}, Allergenic: true, CrossReactive: []string{ClassCephalosporin, ClassCarbapenem}},
+	{Name: ClassCephalosporin, Label: "cephalosporins", Allergenic: true, CrossReactive: []string{ClassPenicillin, ClassCarbapenem}},
+	{Name: ClassCarbapenem, Label: "carbapenems", Allergenic: true, CrossReactive: []string{ClassPenicillin, ClassCephalosporin}},
+	{Name: ClassSulfonamide, Label: "sulfonamides", Aliases: []string{"sulfa", "sulfa drugs", "sulpha", "potentiated sulfonamides"}, Allergenic: true},
+	{Name: ClassFluoroquinolone, Label: "fluoroquinolones", Aliases: []string{"quinolones"}, Allergenic: true},
+	{Name: ClassTetracycline, Label: "tetracyclines", Allergenic: true},
+	{Name: ClassMacrolide, Label: "macrolides", Allergenic: true},
+	{Name: ClassLincosamide, Label: "lincosamides", Allergenic: true},
+	{Name: ClassNitroimidazole, Label: "nitroimidazoles", Allergenic: true},
+	{Name: ClassAminoglycoside, Label: "aminoglycosides", Allergenic: true},
+	{Name: ClassNSAID, Label: "NSAIDs", Aliases: []string{"non steroidal anti inflammatories", "non steroidal anti inflammatory drugs", "nsaid"}, Allergenic: true},
+	{Name: ClassCorticosteroid, Label: "corticosteroids", Aliases: []string{"steroids", "glucocorticoids"}},
+	{Name: ClassOpioid, Label: "opioids", Aliases: []string{"opiates"}, Allergenic: true},
+	{Name: ClassSerotonergic, Label: "serotonergic drugs"},
+	{Name: ClassBenzodiazepine, Label: "benzodiazepines"},
+	{Name: ClassAlpha2Agonist, Label: "alpha-2 agonists"},
+	{Name: ClassAntihistamine, Label: "antihistamines"},
+	{Name: ClassAzoleAntifungal, Label: "azole antifungals", Aliases: []string{"azoles"}},
+	{Name: ClassMacrocyclicLact, Label: "macrocyclic lactones", Aliases: []string{"avermectins"}, Allergenic: true},
+	{Name: ClassIsoxazoline, Label: "isoxazolines", Allergenic: true},
+	{Name: ClassPyrethroid, Label: "pyrethroids", Aliases: []string{"pyrethrins"}, Allergenic: true},
+	{Name: ClassACEInhibitor, Label: "ACE inhibitors"},
+	{Name: ClassLoopDiuretic, Label: "loop diuretics"},
+	{Name: ClassAnticonvulsant, Label: "anticonvulsants"},
+	{Name: ClassAntiemetic, Label: "antiemetics"},
+	{Name: ClassGastroprotect, Label: "gastroprotectants"},
+	{Name: ClassLocalAnesthetic, Label: "local anesthetics", Aliases: []string{"local anaesthetics"}, Allergenic: true},
+	{Name: ClassVaccine, Label: "vaccines"},
+}
+
+var drugs = []Drug{
+	// Penicillins
+	{Name: "penicillin", Aliases: []string{"penicillin g", "benzylpenicillin", "procaine penicillin", "penicillin v"}, Classes: []string{ClassPenicillin}},
+	{Name: "amoxicillin", Aliases: []string{"amoxycillin", "amoxil", "amoxi tabs", "amoxi drops"}, Classes: []string{ClassPenicillin}},
+	{Name: "amoxicillin-clavulanate", Aliases: []string{"amoxicillin clavulanic acid", "amoxicillin with clavulanic acid", "amoxicillin/clavulanic acid", "amox clav", "amoxi clav", "clavamox", "synulox", "augmentin"}, Classes: []string{ClassPenicillin}},
+	{Name: "ampicillin", Aliases: []string{"ampicillin sulbactam", "unasyn"}, Classes: []string{ClassPenicillin}},
+	{Name: "cloxacillin", Classes: []string{ClassPenicillin}},
+	{Name: "ticarcillin", Classes: []string{ClassPenicillin}},
+
+	// Cephalosporins
+	{Name: "cephalexin", Aliases: []string{"cefalexin", "keflex", "rilexine", "vetolexin"}, Classes: []string{ClassCephalosporin}},
+	{Name: "cefazolin", Aliases: []string{"cephazolin"}, Classes: []string{ClassCephalosporin}},
+	{Name: "cefpodoxime", Aliases: []string{"simplicef"}, Classes: []string{ClassCephalosporin}},
+	{Name: "cefovecin", Aliases: []string{"convenia"}, Classes: []string{ClassCephalosporin}},
+	{Name: "ceftiofur", Aliases: []string{"excenel", "naxcel"}, Classes: []string{ClassCephalosporin}},
+	{Name: "cefadroxil", Classes: []string{ClassCephalosporin}},
+	{Name: "cefoxitin", Classes: []string{ClassCephalosporin}},
+	{Name: "imipenem", Classes: []string{ClassCarbapenem}},
+	{Name: "meropenem", Classes: []string{ClassCarbapenem}},
+
+	// Sulfonamides
+	{Name: "trimethoprim-sulfamethoxazole", Aliases: []string{"trimethoprim sulfa", "tmp sulfa", "tmp smx", "sulfamethoxazole trimethoprim", "bactrim", "septra"}, Classes: []string{ClassSulfonamide}},
+	{Name: "trimethoprim-sulfadiazine", Aliases: []string{"tribrissen", "sulfadiazine trimethoprim"}, Classes: []string{ClassSulfonamide}},
+	{Name: "sulfadimethoxine", Aliases: []string{"albon"}, Classes: []string{ClassSulfonamide}},
+	{Name: "sulfasalazine", Classes: []string{ClassSulfonamide}},
+
+	// Fluoroquinolones
+	{Name: "enrofloxacin", Aliases: []string{"baytril"}, Classes: []string{ClassFluoroquinolone}},
+	{Name: "marbofloxacin", Aliases: []string{"zeniquin", "marbocyl"}, Classes: []string{ClassFluoroquinolone}},
+	{Name: "pradofloxacin", Aliases: []string{"veraflox"}, Classes: []string{ClassFluoroquinolone}},
+	{Name: "orbifloxacin", Classes: []string{ClassFluoroquinolone}},
+	{Name: "ciprofloxacin", Aliases: []string{"cipro"}, Classes: []string{ClassFluoroquinolone}},
+
+	// Tetracyclines, macrolides, lincosamides and others
+	{Name: "doxycycline", Aliases: []string{"vibramycin", "ronaxan"}, Classes: []string{ClassTetracycline}},
+	{Name: "minocycline", Classes: []string{ClassTetracycline}},
+	{Name: "oxytetracycline", Classes: []string{ClassTetracycline}},
+	{Name: "tetracycline", Classes: []string{ClassTetracycline}},
+	{Name: "azithromycin", Aliases: []string{"zithromax"}, Classes: []string{ClassMacrolide}},
+	{Name: "erythromycin", Classes: []string{ClassMacrolide}},
+	{Name: "tylosin", Aliases: []string{"tylan"}, Classes: []string{ClassMacrolide}},
+	{Name: "clindamycin", Aliases: []string{"antirobe", "clindrops"}, Classes: []string{ClassLincosamide}},
+	{Name: "lincomycin", Classes: []string{ClassLincosamide}},
+	{Name: "metronidazole", Aliases: []string{"flagyl"}, Classes: []string{ClassNitroimidazole}},
+	{Name: "gentamicin", Classes: []string{ClassAminoglycoside}},
+	{Name: "amikacin", Classes: []string{ClassAminoglycoside}},
+	{Name: "neomycin", Classes: []string{ClassAminoglycoside}},
+
+	// NSAIDs
+	{Name: "meloxicam", Aliases: []string{"metacam", "loxicom", "meloxidyl"}, Classes: []string{ClassNSAID}},
+	{Name: "carprofen", Aliases: []string{"rimadyl", "novox", "vetprofen", "carprieve"}, Classes: []string{ClassNSAID}},
+	{Name: "robenacoxib", Aliases: []string{"onsior"}, Classes: []string{ClassNSAID}},
+	{Name: "firocoxib", Aliases: []string{"previcox"}, Classes: []string{ClassNSAID}},
+	{Name: "deracoxib", Aliases: []string{"deramaxx"}, Classes: []string{ClassNSAID}},
+	{Name: "grapiprant", Aliases: []string{"galliprant"}, Classes: []string{ClassNSAID}},
+	{Name: "ketoprofen", Aliases: []string{"ketofen"}, Classes: []string{ClassNSAID}},
+	{Name: "aspirin", Aliases: []string{"acetylsalicylic acid"}, Classes: []string{ClassNSAID}},
+	{Name: "ibuprofen", Aliases: []string{"advil", "motrin"}, Classes: []string{ClassNSAID}},
+	{Name: "naproxen", Classes: []string{ClassNSAID}},
+	{Name: "acetaminophen", Aliases: []string{"paracetamol", "tylenol"}},
+
+	// Corticosteroids
+	{Name: "prednisolone", Aliases: []string{"pred"}, Classes: []string{ClassCorticosteroid}},
+	{Name: "prednisone", Classes: []string{ClassCorticosteroid}},
+	{Name: "dexamethasone", Aliases: []string{"azium"}, Classes: []string{ClassCorticosteroid}},
+	{Name: "methylprednisolone", Aliases: []string{"depo medrol", "medrol"}, Classes: []string{ClassCorticosteroid}},
+	{Name: "triamcinolone", Aliases: []string{"vetalog"}, Classes: []string{ClassCorticosteroid}},
+	{Name: "budesonide", Classes: []string{ClassCorticosteroid}},
+	{Name: "hydrocortisone", Classes: []string{ClassCorticosteroid}},
+	{Name: "fludrocortisone", Classes: []string{ClassCorticosteroid}},
+
+	// Opioids
+	{Name: "tramadol", Classes: []string{ClassOpioid, ClassSerotonergic}},
+	{Name: "buprenorphine", Aliases: []string{"buprenex", "simbadol", "vetergesic"}, Classes: []string{ClassOpioid}},
+	{Name: "butorphanol", Aliases: []string{"torbugesic", "torbutrol"}, Classes: []string{ClassOpioid}},
+	{Name: "methadone", Classes: []string{ClassOpioid, ClassSerotonergic}},
+	{Name: "morphine", Classes: []string{ClassOpioid}},
+	{Name: "hydromorphone", Classes: []string{ClassOpioid}},
+	{Name: "fentanyl", Aliases: []string{"recuvyra"}, Classes: []string{ClassOpioid, ClassSerotonergic}},
+	{Name: "codeine", Classes: []string{ClassOpioid}},
+
+	// Serotonergic drugs
+	{Name: "fluoxetine", Aliases: []string{"reconcile", "prozac"}, Classes: []string{ClassSerotonergic}},
+	{Name: "clomipramine", Aliases: []string{"clomicalm"}, Classes: []string{ClassSerotonergic}},
+	{Name: "amitriptyline", Classes: []string{ClassSerotonergic}},
+	{Name: "sertraline", Classes: []string{ClassSerotonergic}},
+	{Name: "paroxetine", Classes: []string{ClassSerotonergic}},
+	{Name: "trazodone", Classes: []string{ClassSerotonergic}},
+	{Name: "selegiline", Aliases: []string{"anipryl"}, Classes: []string{ClassSerotonergic}},
+	{Name: "mirtazapine", Aliases: []string{"mirataz"}, Classes: []string{ClassSerotonergic}},
+	{Name: "buspirone", Classes: []string{ClassSerotonergic}},
+	{Name: "ondansetron", Aliases: []string{"zofran"}, Classes: []string{ClassSerotonergic, ClassAntiemetic}},
+	{Name: "amitraz", Aliases: []string{"mitaban"}, Classes: []string{ClassSerotonergic}},
+	{Name: "cyproheptadine", Classes: []string{ClassAntihistamine}},
+
+	// Sedatives, anesthetics and anticonvulsants
+	{Name: "diazepam", Aliases: []string{"valium"}, Classes: []string{ClassBenzodiazepine}},
+	{Name: "midazolam", Classes: []string{ClassBenzodiazepine}},
+	{Name: "alprazolam", Aliases: []string{"xanax"}, Classes: []string{ClassBenzodiazepine}},
+	{Name: "dexmedetomidine", Aliases: []string{"dexdomitor", "sileo"}, Classes: []string{ClassAlpha2Agonist}},
+	{Name: "medetomidine", Aliases: []string{"domitor"}, Classes: []string{ClassAlpha2Agonist}},
+	{Name: "xylazine", Classes: []string{ClassAlpha2Agonist}},
+	{Name: "acepromazine", Aliases: []string{"acp"}},
+	{Name: "gabapentin", Aliases: []string{"neurontin"}, Classes: []string{ClassAnticonvulsant}},
+	{Name: "phenobarbital", Aliases: []string{"phenobarbitone", "pb"}, Classes: []string{ClassAnticonvulsant}},
+	{Name: "levetiracetam", Aliases: []string{"keppra"}, Classes: []string{ClassAnticonvulsant}},
+	{Name: "potassium bromide", Aliases: []string{"kbr"}, Classes: []string{ClassAnticonvulsant}},
+	{Name: "zonisamide", Classes: []string{ClassAnticonvulsant, ClassSulfonamide}},
+	{Name: "ketamine"},
+	{Name: "propofol", Aliases: []string{"propoflo"}},
+	{Name: "alfaxalone", Aliases: []string{"alfaxan"}},
+	{Name: "lidocaine", Aliases: []string{"lignocaine"}, Classes: []string{ClassLocalAnesthetic}},
+	{Name: "bupivacaine", Aliases: []string{"nocita"}, Classes: []string{ClassLocalAnesthetic}},
+
+	// Gastrointestinal
+	{Name: "maropitant", Aliases: []string{"cerenia"}, Classes: []string{ClassAntiemetic}},
+	{Name: "metoclopramide", Aliases: []string{"reglan"}, Classes: []string{ClassAntiemetic}},
+	{Name: "omeprazole", Aliases: []string{"gastrogard", "prilosec"}, Classes: []string{ClassGastroprotect}},
+	{Name: "famotidine", Aliases: []string{"pepcid"}, Classes: []string{ClassGastroprotect}},
+	{Name: "sucralfate", Aliases: []string{"carafate"}, Classes: []string{ClassGastroprotect}},
+	{Name: "misoprostol", Classes: []string{ClassGastroprotect}},
+
+	// Cardiac and renal
+	{Name: "pimobendan", Aliases: []string{"vetmedin"}},
+	{Name: "enalapril", Aliases: []string{"enacard"}, Classes: []string{ClassACEInhibitor}},
+	{Name: "benazepril", Aliases: []string{"fortekor", "lotensin"}, Classes: []string{ClassACEInhibitor}},
+	{Name: "furosemide", Aliases: []string{"frusemide", "lasix", "salix"}, Classes: []string{ClassLoopDiuretic}},
+	{Name: "torsemide", Aliases: []string{"torasemide", "upcard"}, Classes: []string{ClassLoopDiuretic}},
+	{Name: "spironolactone", Aliases: []string{"prilactone"}},
+	{Name: "amlodipine"},
+	{Name: "digoxin"},
+	{Name: "clopidogrel", Aliases: []string{"plavix"}},
+
+	// Endocrine
+	{Name: "methimazole", Aliases: []string{"felimazole", "tapazole"}},
+	{Name: "levothyroxine", Aliases: []string{"soloxine", "thyro tabs"}},
+	{Name: "insulin", Aliases: []string{"vetsulin", "caninsulin", "prozinc", "glargine"}},
+	{Name: "trilostane", Aliases: []string{"vetoryl"}},
+
+	// Dermatology, antihistamines and antifungals
+	{Name: "oclacitinib", Aliases: []string{"apoquel"}},
+	{Name: "lokivetmab", Aliases: []string{"cytopoint"}},
+	{Name: "cyclosporine", Aliases: []string{"ciclosporin", "atopica"}},
+	{Name: "diphenhydramine", Aliases: []string{"benadryl"}, Classes: []string{ClassAntihistamine}},
+	{Name: "cetirizine", Aliases: []string{"zyrtec"}, Classes: []string{ClassAntihistamine}},
+	{Name: "chlorpheniramine", Classes: []string{ClassAntihistamine}},
+	{Name: "hydroxyzine", Classes: []string{ClassAntihistamine}},
+	{Name: "ketoconazole", Classes: []string{ClassAzoleAntifungal}},
+	{Name: "itraconazole", Aliases: []string{"itrafungol"}, Classes: []string{ClassAzoleAntifungal}},
+	{Name: "fluconazole", Classes: []string{ClassAzoleAntifungal}},
+	{Name: "terbinafine"},
+
+	// Parasiticides
+	{Name: "ivermectin", Aliases: []string{"heartgard"}, Classes: []string{ClassMacrocyclicLact}},
+	{Name: "milbemycin", Aliases: []string{"milbemycin oxime", "interceptor", "milbemax"}, Classes: []string{ClassMacrocyclicLact}},
+	{Name: "selamectin", Aliases: []string{"stronghold"}, Classes: []string{ClassMacrocyclicLact}},
+	{Name: "moxidectin", Aliases: []string{"proheart"}, Classes: []string{ClassMacrocyclicLact}},
+	{Name: "fluralaner", Aliases: []string{"bravecto"}, Classes: []string{ClassIsoxazoline}},
+	{Name: "afoxolaner", Aliases: []string{"nexgard"}, Classes: []string{ClassIsoxazoline}},
+	{Name: "sarolaner", Aliases: []string{"simparica"}, Classes: []string{ClassIsoxazoline}},
+	{Name: "lotilaner", Aliases: []string{"credelio"}, Classes: []string{ClassIsoxazoline}},
+	{Name: "permethrin", Aliases: []string{"k9 advantix"}, Classes: []string{ClassPyrethroid}},
+	{Name: "fipronil", Aliases: []string{"frontline"}},
+	{Name: "imidacloprid"},
+	{Name: "praziquantel", Aliases: []string{"droncit"}},
+	{Name: "fenbendazole", Aliases: []string{"panacur"}},
+	{Name: "pyrantel", Aliases: []string{"pyrantel pamoate", "strongid", "nemex"}},
+}
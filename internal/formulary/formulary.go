@@ -0,0 +1,191 @@
+// Package formulary holds the local drug dictionary and the deterministic
+// medication safety checks run against generated treatment plans.
+package formulary
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Drug is a dictionary entry. Name is the canonical generic name; Aliases
+// include brand names and common abbreviations.
+type Drug struct {
+	Name    string
+	Aliases []string
+	Classes []string
+}
+
+// Class groups drugs that share a mechanism or structure.
+type Class struct {
+	Name  string
+	Label string
+	// Aliases are names used for the whole class, e.g. "sulfa drugs".
+	Aliases []string
+	// Allergenic marks classes where a hypersensitivity to one member is
+	// expected to extend to the others.
+	Allergenic bool
+	// CrossReactive lists classes with partial hypersensitivity overlap.
+	CrossReactive []string
+}
+
+// HasClass reports whether the drug belongs to class.
+func (d *Drug) HasClass(class string) bool {
+	for _, c := range d.Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Mention is a drug found in free text.
+type Mention struct {
+	Drug *Drug
+	// Text is the alias as it matched.
+	Text string
+}
+
+type aliasEntry struct {
+	alias string
+	drug  *Drug
+}
+
+type classAliasEntry struct {
+	alias string
+	class *Class
+}
+
+var (
+	drugsByName = map[string]*Drug{}
+	classByName = map[string]*Class{}
+	// aliases are sorted longest first so combination products win over
+	// their components.
+	aliases      []aliasEntry
+	classAliases []classAliasEntry
+)
+
+func init() {
+	for i := range classes {
+		c := &classes[i]
+		classByName[c.Name] = c
+		for _, a := range append([]string{c.Name, c.Label}, c.Aliases...) {
+			classAliases = append(classAliases, classAliasEntry{alias: normalize(a), class: c})
+		}
+	}
+	for i := range drugs {
+		d := &drugs[i]
+		drugsByName[normalize(d.Name)] = d
+		for _, a := range append([]string{d.Name}, d.Aliases...) {
+			aliases = append(aliases, aliasEntry{alias: normalize(a), drug: d})
+		}
+	}
+	sort.SliceStable(aliases, func(i, j int) bool { return len(aliases[i].alias) > len(aliases[j].alias) })
+	sort.SliceStable(classAliases, func(i, j int) bool { return len(classAliases[i].alias) > len(classAliases[j].alias) })
+}
+
+// LookupClass returns a drug class by name.
+func LookupClass(name string) (*Class, bool) {
+	c, ok := classByName[name]
+	return c, ok
+}
+
+// Lookup returns the drug whose name or alias is exactly name.
+func Lookup(name string) (*Drug, bool) {
+	n := normalize(name)
+	if d, ok := drugsByName[n]; ok {
+		return d, true
+	}
+	for _, a := range aliases {
+		if a.alias == n {
+			return a.drug, true
+		}
+	}
+	return nil, false
+}
+
+// FindDrugs returns every dictionary drug mentioned in text, in order of
+// appearance, without duplicates.
+func FindDrugs(text string) []Mention {
+	padded := " " + normalize(text) + " "
+	type hit struct {
+		start int
+		m     Mention
+	}
+	var hits []hit
+	taken := make([]bool, len(padded))
+	seen := map[*Drug]bool{}
+
+	for _, a := range aliases {
+		needle := " " + a.alias + " "
+		for from := 0; ; {
+			i := strings.Index(padded[from:], needle)
+			if i < 0 {
+				break
+			}
+			start := from + i
+			end := start + len(needle)
+			from = start + 1
+			if overlaps(taken, start+1, end-1) {
+				continue
+			}
+			for k := start + 1; k < end-1; k++ {
+				taken[k] = true
+			}
+			if !seen[a.drug] {
+				seen[a.drug] = true
+				hits = append(hits, hit{start: start, m: Mention{Drug: a.drug, Text: a.alias}})
+			}
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].start < hits[j].start })
+	mentions := make([]Mention, len(hits))
+	for i, h := range hits {
+		mentions[i] = h.m
+	}
+	return mentions
+}
+
+// FindClasses returns drug classes named in text, such as "penicillins" or
+// "sulfa drugs".
+func FindClasses(text string) []*Class {
+	padded := " " + normalize(text) + " "
+	var found []*Class
+	seen := map[*Class]bool{}
+	for _, a := range classAliases {
+		if !seen[a.class] && strings.Contains(padded, " "+a.alias+" ") {
+			seen[a.class] = true
+			found = append(found, a.class)
+		}
+	}
+	return found
+}
+
+func overlaps(taken []bool, start, end int) bool {
+	for k := start; k < end; k++ {
+		if taken[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize lower-cases text and turns punctuation into single spaces so
+// "Amoxicillin/Clavulanate" and "amoxicillin-clavulanate" compare equal.
+func normalize(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,179 @@
+package formulary
+
+// Interaction severities, from most to least serious.
+const (
+	InteractionContraindicated = "contraindicated"
+	InteractionMajor           = "major"
+	InteractionModerate        = "moderate"
+	InteractionMinor           = "minor"
+)
+
+var interactionRank = map[string]int{
+	InteractionContraindicated: 4,
+	InteractionMajor:           3,
+	InteractionModerate:        2,
+	InteractionMinor:           1,
+}
+
+// interactionRule pairs two drugs or drug classes. A rule whose sides are the
+// same class applies to two different drugs of that class.
+type interactionRule struct {
+	A, B       string
+	Severity   string
+	Effect     string
+	Management string
+}
+
+var interactionRules = []interactionRule{
+	{ClassNSAID, ClassCorticosteroid, InteractionMajor,
+		"Gastrointestinal ulceration and perforation",
+		"Avoid combining; allow a washout of 5–7 days when switching between them"},
+	{ClassNSAID, ClassNSAID, InteractionMajor,
+		"Additive gastrointestinal and renal toxicity",
+		"Use one NSAID; allow a washout of 5–7 days when switching"},
+	{"selegiline", ClassSerotonergic, InteractionContraindicated,
+		"Serotonin syndrome with a monoamine oxidase inhibitor",
+		"Do not combine; allow at least 2 weeks after stopping selegiline"},
+	{"amitraz", ClassSerotonergic, InteractionContraindicated,
+		"Serotonin syndrome; amitraz has monoamine oxidase inhibitor activity",
+		"Do not combine"},
+	{ClassSerotonergic, ClassSerotonergic, InteractionMajor,
+		"Serotonin syndrome: agitation, tremors, hyperthermia, tachycardia",
+		"Avoid combining or use the lowest doses and monitor closely"},
+	{ClassNSAID, ClassAminoglycoside, InteractionMajor,
+		"Additive nephrotoxicity",
+		"Avoid combining; if unavoidable, monitor renal values"},
+	{ClassLoopDiuretic, ClassAminoglycoside, InteractionMajor,
+		"Increased ototoxicity and nephrotoxicity",
+		"Avoid combining"},
+	{ClassNSAID, ClassACEInhibitor, InteractionModerate,
+		"Reduced renal perfusion and blunted antihypertensive effect",
+		"Monitor renal values and blood pressure"},
+	{ClassNSAID, ClassLoopDiuretic, InteractionModerate,
+		"Reduced renal perfusion and diuretic effect",
+		"Monitor renal values and hydration"},
+	{ClassNSAID, "clopidogrel", InteractionModerate,
+		"Increased bleeding risk",
+		"Monitor for bleeding"},
+	{ClassACEInhibitor, "spironolactone", InteractionModerate,
+		"Hyperkalemia",
+		"Monitor potassium"},
+	{ClassACEInhibitor, "trilostane", InteractionModerate,
+		"Hyperkalemia",
+		"Monitor potassium"},
+	{"digoxin", ClassLoopDiuretic, InteractionModerate,
+		"Hypokalemia increases the risk of digoxin toxicity",
+		"Monitor potassium and digoxin levels"},
+	{ClassAzoleAntifungal, "cyclosporine", InteractionModerate,
+		"Raised cyclosporine levels",
+		"Reduce the cyclosporine dose and monitor levels"},
+	{ClassAzoleAntifungal, ClassBenzodiazepine, InteractionModerate,
+		"Raised benzodiazepine levels and prolonged sedation",
+		"Reduce the benzodiazepine dose"},
+	{ClassAzoleAntifungal, ClassMacrocyclicLact, InteractionModerate,
+		"P-glycoprotein inhibition increases neurotoxicity risk",
+		"Avoid high-dose macrocyclic lactones"},
+	{ClassAzoleAntifungal, ClassGastroprotect, InteractionModerate,
+		"Reduced absorption of ketoconazole and itraconazole capsules",
+		"Give with food or use a formulation that does not need gastric acid"},
+	{"sucralfate", ClassFluoroquinolone, InteractionModerate,
+		"Reduced fluoroquinolone absorption",
+		"Give the fluoroquinolone at least 2 hours before sucralfate"},
+	{"sucralfate", ClassTetracycline, InteractionModerate,
+		"Reduced tetracycline absorption",
+		"Give the tetracycline at least 2 hours before sucralfate"},
+	{"metoclopramide", "acepromazine", InteractionModerate,
+		"Increased risk of extrapyramidal signs",
+		"Avoid combining"},
+	{"insulin", ClassCorticosteroid, InteractionModerate,
+		"Corticosteroids raise blood glucose and insulin requirements",
+		"Monitor glucose and adjust insulin"},
+	{ClassOpioid, ClassBenzodiazepine, InteractionMinor,
+		"Additive sedation and respiratory depression",
+		"Monitor sedation and breathing"},
+	{ClassOpioid, ClassAlpha2Agonist, InteractionMinor,
+		"Additive sedation, bradycardia and respiratory depression",
+		"Monitor heart rate and breathing"},
+	{"phenobarbital", ClassBenzodiazepine, InteractionMinor,
+		"Additive sedation",
+		"Monitor sedation"},
+	{"phenobarbital", "metronidazole", InteractionMinor,
+		"Phenobarbital speeds metronidazole clearance",
+		"Consider a higher metronidazole dose"},
+}
+
+// InteractionAlert is a drug–drug interaction between two medications.
+type InteractionAlert struct {
+	DrugA      string `json:"drug_a"`
+	DrugB      string `json:"drug_b"`
+	Severity   string `json:"severity"`
+	Effect     string `json:"effect"`
+	Management string `json:"management"`
+	// Proposed is set when at least one of the drugs is newly proposed
+	// rather than already being taken.
+	Proposed bool `json:"proposed"`
+}
+
+// CheckInteractions checks every pair in the union of the current and the
+// proposed medications and returns the most severe interaction per pair. A
+// drug in both lists is a continuation, not a pair.
+func CheckInteractions(current, proposed []string) []InteractionAlert {
+	type entry struct {
+		drug     *Drug
+		proposed bool
+	}
+	var entries []entry
+	seen := map[*Drug]bool{}
+	add := func(medications []string, proposed bool) {
+		for _, m := range medications {
+			for _, mention := range FindDrugs(m) {
+				if seen[mention.Drug] {
+					continue
+				}
+				seen[mention.Drug] = true
+				entries = append(entries, entry{drug: mention.Drug, proposed: proposed})
+			}
+		}
+	}
+	add(current, false)
+	add(proposed, true)
+
+	var alerts []InteractionAlert
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			a, b := entries[i], entries[j]
+			rule, ok := matchInteraction(a.drug, b.drug)
+			if !ok {
+				continue
+			}
+			alerts = append(alerts, InteractionAlert{
+				DrugA:      a.drug.Name,
+				DrugB:      b.drug.Name,
+				Severity:   rule.Severity,
+				Effect:     rule.Effect,
+				Management: rule.Management,
+				Proposed:   a.proposed || b.proposed,
+			})
+		}
+	}
+	return alerts
+}
+
+// matchInteraction returns the most severe rule that applies to the pair.
+func matchInteraction(a, b *Drug) (interactionRule, bool) {
+	var best interactionRule
+	found := false
+	for _, rule := range interactionRules {
+		if !(matchesTerm(a, rule.A) && matchesTerm(b, rule.B)) && !(matchesTerm(b, rule.A) && matchesTerm(a, rule.B)) {
+			continue
+		}
+		if !found || interactionRank[rule.Severity] > interactionRank[best.Severity] {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
+
+func matchesTerm(d *Drug, term string) bool {
+	return d.Name == term || d.HasClass(term)
+}
@@ -0,0 +1,36 @@
+package formulary
+
+import "testing"
+
+func TestCheckInteractions(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      []string
+		proposed     []string
+		wantSeverity string
+		wantProposed bool
+	}{
+		{"NSAID and corticosteroid", []string{"meloxicam 0.1 mg/kg PO SID"}, []string{"prednisolone 1 mg/kg PO SID"}, InteractionMajor, true},
+		{"two NSAIDs", nil, []string{"carprofen 2.2 mg/kg PO BID", "Metacam 0.1 mg/kg PO SID"}, InteractionMajor, true},
+		{"existing pair", []string{"meloxicam", "dexamethasone"}, nil, InteractionMajor, false},
+		{"continued drug is not a pair", []string{"meloxicam 0.1 mg/kg"}, []string{"meloxicam 0.05 mg/kg"}, "", false},
+		{"no interaction", []string{"amoxicillin"}, []string{"maropitant"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts := CheckInteractions(tt.current, tt.proposed)
+			if tt.wantSeverity == "" {
+				if len(alerts) != 0 {
+					t.Errorf("alerts = %+v, want none", alerts)
+				}
+				return
+			}
+			if len(alerts) != 1 {
+				t.Fatalf("alerts = %+v, want one", alerts)
+			}
+			if alerts[0].Severity != tt.wantSeverity || alerts[0].Proposed != tt.wantProposed {
+				t.Errorf("alert = %+v, want %s, proposed %v", alerts[0], tt.wantSeverity, tt.wantProposed)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package formulary
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Canonical administration routes.
+const (
+	RouteOral          = "oral"
+	RouteSubcutaneous  = "subcutaneous"
+	RouteIntramuscular = "intramuscular"
+	RouteIntravenous   = "intravenous"
+	RouteTopical       = "topical"
+	RouteOtic          = "otic"
+	RouteOphthalmic    = "ophthalmic"
+	RouteTransdermal   = "transdermal"
+	RouteInhaled       = "inhaled"
+	RouteRectal        = "rectal"
+)
+
+var routeAliases = map[string]string{
+	"po": RouteOral, "oral": RouteOral, "orally": RouteOral, "by mouth": RouteOral,
+	"sc": RouteSubcutaneous, "sq": RouteSubcutaneous, "subq": RouteSubcutaneous, "subcut": RouteSubcutaneous, "subcutaneous": RouteSubcutaneous, "subcutaneously": RouteSubcutaneous,
+	"im": RouteIntramuscular, "intramuscular": RouteIntramuscular, "intramuscularly": RouteIntramuscular,
+	"iv": RouteIntravenous, "intravenous": RouteIntravenous, "intravenously": RouteIntravenous,
+	"topical": RouteTopical, "topically": RouteTopical, "spot on": RouteTopical,
+	"otic": RouteOtic, "au": RouteOtic, "in ear": RouteOtic, "in ears": RouteOtic,
+	"ophthalmic": RouteOphthalmic, "ou": RouteOphthalmic, "in eye": RouteOphthalmic, "in eyes": RouteOphthalmic,
+	"transdermal": RouteTransdermal, "td": RouteTransdermal,
+	"inhaled": RouteInhaled, "nebulized": RouteInhaled,
+	"rectal": RouteRectal, "pr": RouteRectal, "per rectum": RouteRectal,
+}
+
+// Canonical dosing frequencies.
+const (
+	FrequencyOnce = "once"
+	FrequencyQ24h = "q24h"
+	FrequencyQ12h = "q12h"
+	FrequencyQ8h  = "q8h"
+	FrequencyQ6h  = "q6h"
+	FrequencyQ48h = "q48h"
+	FrequencyQ7d  = "weekly"
+	FrequencyPRN  = "as needed"
+)
+
+var frequencyAliases = map[string]string{
+	"once": FrequencyOnce, "single dose": FrequencyOnce, "one time": FrequencyOnce, "stat": FrequencyOnce,
+	"sid": FrequencyQ24h, "qd": FrequencyQ24h, "q24h": FrequencyQ24h, "q24": FrequencyQ24h, "once daily": FrequencyQ24h, "once a day": FrequencyQ24h, "daily": FrequencyQ24h, "every 24 hours": FrequencyQ24h,
+	"bid": FrequencyQ12h, "q12h": FrequencyQ12h, "q12": FrequencyQ12h, "twice daily": FrequencyQ12h, "twice a day": FrequencyQ12h, "every 12 hours": FrequencyQ12h,
+	"tid": FrequencyQ8h, "q8h": FrequencyQ8h, "q8": FrequencyQ8h, "three times daily": FrequencyQ8h, "three times a day": FrequencyQ8h, "every 8 hours": FrequencyQ8h,
+	"qid": FrequencyQ6h, "q6h": FrequencyQ6h, "q6": FrequencyQ6h, "four times daily": FrequencyQ6h, "four times a day": FrequencyQ6h, "every 6 hours": FrequencyQ6h,
+	"eod": FrequencyQ48h, "q48h": FrequencyQ48h, "every other day": FrequencyQ48h, "every 48 hours": FrequencyQ48h,
+	"weekly": FrequencyQ7d, "once weekly": FrequencyQ7d, "once a week": FrequencyQ7d, "q7d": FrequencyQ7d,
+	"prn": FrequencyPRN, "as needed": FrequencyPRN,
+}
+
+var dosesPerDay = map[string]float64{
+	FrequencyQ24h: 1,
+	FrequencyQ12h: 2,
+	FrequencyQ8h:  3,
+	FrequencyQ6h:  4,
+	FrequencyQ48h: 0.5,
+	FrequencyQ7d:  1.0 / 7,
+}
+
+// Dose units as written in orders. Weight-based units keep the "/kg".
+var unitAliases = map[string]string{
+	"mg": "mg", "milligram": "mg", "milligrams": "mg",
+	"mcg": "mcg", "ug": "mcg", "microgram": "mcg", "micrograms": "mcg",
+	"g": "g", "gram": "g", "grams": "g",
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml", "cc": "ml",
+	"iu": "iu", "units": "iu", "unit": "iu", "u": "iu",
+	"tablet": "tablet", "tablets": "tablet", "tab": "tablet", "tabs": "tablet",
+	"capsule": "capsule", "capsules": "capsule", "cap": "capsule", "caps": "capsule",
+	"drop": "drop", "drops": "drop", "gtt": "drop", "gtts": "drop",
+	"pipette": "pipette", "pipettes": "pipette", "chew": "chew", "chews": "chew",
+}
+
+// NormalizeRoute maps an abbreviation such as "PO" or "SQ" to its canonical
+// route. Unknown routes are returned lower-cased.
+func NormalizeRoute(route string) string {
+	r := normalize(route)
+	if canonical, ok := routeAliases[r]; ok {
+		return canonical
+	}
+	return r
+}
+
+// NormalizeFrequency maps "BID", "twice daily" and the like to a canonical
+// interval such as "q12h". Unknown frequencies are returned lower-cased.
+func NormalizeFrequency(frequency string) string {
+	f := normalize(frequency)
+	if canonical, ok := frequencyAliases[f]; ok {
+		return canonical
+	}
+	return f
+}
+
+// DosesPerDay returns how many doses a canonical frequency gives per day, or
+// 0 when it is a single dose, as needed or unknown.
+func DosesPerDay(frequency string) float64 {
+	return dosesPerDay[frequency]
+}
+
+// NormalizeUnit maps a dose unit to its canonical spelling, keeping any
+// per-kilogram suffix: "Mg/Kg" becomes "mg/kg".
+func NormalizeUnit(unit string) string {
+	u := strings.ToLower(strings.TrimSpace(unit))
+	perKg := strings.HasSuffix(u, "/kg")
+	u = strings.TrimSpace(strings.TrimSuffix(u, "/kg"))
+	if canonical, ok := unitAliases[u]; ok {
+		u = canonical
+	}
+	if perKg {
+		return u + "/kg"
+	}
+	return u
+}
+
+// Order is a medication order parsed from free text.
+type Order struct {
+	Drug string
+	// Known is set when Drug was found in the dictionary.
+	Known        bool
+	Strength     string
+	Dose         float64
+	Unit         string
+	Route        string
+	Frequency    string
+	DurationDays int
+	Quantity     float64
+	DispenseUnit string
+}
+
+var (
+	number       = `(\d+(?:\.\d+)?)`
+	unitPattern  = `(mg|mcg|ug|g|ml|cc|iu|units?|tablets?|tabs?|capsules?|caps?|drops?|gtts?|pipettes?|chews?)`
+	strengthRe   = regexp.MustCompile(`(?i)` + number + `\s*(mg|mcg|g|iu)(?:\s*/\s*(ml|tablet|tab|capsule|cap|chew))?\s*(?:tablets?|tabs?|capsules?|caps?|chews?|suspension|solution|injection|/\s*ml)`)
+	doseRe       = regexp.MustCompile(`(?i)` + number + `\s*` + unitPattern + `(\s*/\s*kg)?\b`)
+	durationRe   = regexp.MustCompile(`(?i)(?:for|x|×)\s*` + number + `\s*(days?|d|weeks?|wks?|months?)\b`)
+	quantityRe   = regexp.MustCompile(`(?i)(?:dispense|disp|qty|quantity|#)\s*:?\s*` + number + `(?:\s*` + unitPattern + `\b)?`)
+	frequencyRe  = buildAliasPattern(frequencyAliases)
+	routeRe      = buildAliasPattern(routeAliases)
+	daysPerUnits = map[string]int{"d": 1, "day": 1, "days": 1, "week": 7, "weeks": 7, "wk": 7, "wks": 7, "month": 30, "months": 30}
+)
+
+// buildAliasPattern matches any alias as a whole word, longest first.
+func buildAliasPattern(aliases map[string]string) *regexp.Regexp {
+	keys := make([]string, 0, len(aliases))
+	for k := range aliases {
+		keys = append(keys, regexp.QuoteMeta(k))
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(keys, "|") + `)\b`)
+}
+
+// ParseOrder reads a free-text order such as "Amoxicillin 250 mg tablets,
+// 12.5 mg/kg PO BID for 10 days, dispense 20 tablets". Fields that cannot be
+// found are left empty.
+func ParseOrder(text string) Order {
+	var order Order
+	if mentions := FindDrugs(text); len(mentions) > 0 {
+		order.Drug = mentions[0].Drug.Name
+		order.Known = true
+	}
+
+	rest := text
+	if m := quantityRe.FindStringSubmatchIndex(rest); m != nil {
+		order.Quantity, _ = strconv.ParseFloat(rest[m[2]:m[3]], 64)
+		if m[4] >= 0 {
+			order.DispenseUnit = NormalizeUnit(rest[m[4]:m[5]])
+		}
+		rest = rest[:m[0]] + " " + rest[m[1]:]
+	}
+	if m := durationRe.FindStringSubmatch(rest); m != nil {
+		n, _ := strconv.Atoi(strings.Split(m[1], ".")[0])
+		order.DurationDays = n * daysPerUnits[strings.ToLower(m[2])]
+		rest = strings.Replace(rest, m[0], " ", 1)
+	}
+	if m := strengthRe.FindStringSubmatch(rest); m != nil {
+		order.Strength = strings.ToLower(m[1] + " " + m[2])
+		if m[3] != "" {
+			order.Strength += "/" + NormalizeUnit(m[3])
+		}
+		rest = strings.Replace(rest, m[0], " ", 1)
+	}
+	if m := doseRe.FindStringSubmatch(rest); m != nil {
+		order.Dose, _ = strconv.ParseFloat(m[1], 64)
+		unit := m[2]
+		if m[3] != "" {
+			unit += "/kg"
+		}
+		order.Unit = NormalizeUnit(unit)
+	}
+	if m := frequencyRe.FindString(rest); m != "" {
+		order.Frequency = NormalizeFrequency(m)
+	}
+	if m := routeRe.FindString(rest); m != "" {
+		order.Route = NormalizeRoute(m)
+	}
+	return order
+}
@@ -0,0 +1,77 @@
+package formulary
+
+import "testing"
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		text string
+		want Order
+	}{
+		{
+			"Amoxicillin 250 mg tablets, 12.5 mg/kg PO BID for 10 days, dispense 20 tablets",
+			Order{Drug: "amoxicillin", Known: true, Strength: "250 mg", Dose: 12.5, Unit: "mg/kg", Route: RouteOral, Frequency: FrequencyQ12h, DurationDays: 10, Quantity: 20, DispenseUnit: "tablet"},
+		},
+		{
+			"Metacam 1.5 mg/ml suspension 0.1 mg/kg orally once daily x 2 weeks",
+			Order{Drug: "meloxicam", Known: true, Strength: "1.5 mg/ml", Dose: 0.1, Unit: "mg/kg", Route: RouteOral, Frequency: FrequencyQ24h, DurationDays: 14},
+		},
+		{
+			"Cerenia 1 mg/kg SQ q24h",
+			Order{Drug: "maropitant", Known: true, Dose: 1, Unit: "mg/kg", Route: RouteSubcutaneous, Frequency: FrequencyQ24h},
+		},
+		{
+			"Gabapentin 100 mg capsules, 1 cap PO TID",
+			Order{Drug: "gabapentin", Known: true, Strength: "100 mg", Dose: 1, Unit: "capsule", Route: RouteOral, Frequency: FrequencyQ8h},
+		},
+		{
+			"Unknownium 5 mg PO SID",
+			Order{Dose: 5, Unit: "mg", Route: RouteOral, Frequency: FrequencyQ24h},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := ParseOrder(tt.text); got != tt.want {
+				t.Errorf("ParseOrder() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		fn   func(string) string
+		in   string
+		want string
+	}{
+		{NormalizeRoute, "PO", RouteOral},
+		{NormalizeRoute, "sq", RouteSubcutaneous},
+		{NormalizeRoute, "intranasal", "intranasal"},
+		{NormalizeFrequency, "BID", FrequencyQ12h},
+		{NormalizeFrequency, "every other day", FrequencyQ48h},
+		{NormalizeFrequency, "PRN", FrequencyPRN},
+		{NormalizeUnit, "Mg/Kg", "mg/kg"},
+		{NormalizeUnit, "tabs", "tablet"},
+		{NormalizeUnit, "cc", "ml"},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.in); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDosesPerDay(t *testing.T) {
+	tests := map[string]float64{
+		FrequencyQ24h: 1,
+		FrequencyQ12h: 2,
+		FrequencyQ8h:  3,
+		FrequencyQ48h: 0.5,
+		FrequencyOnce: 0,
+		FrequencyPRN:  0,
+	}
+	for frequency, want := range tests {
+		if got := DosesPerDay(frequency); got != want {
+			t.Errorf("DosesPerDay(%q) = %v, want %v", frequency, got, want)
+		}
+	}
+}
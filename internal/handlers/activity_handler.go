@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"vet-tails/ai/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ActivityInput struct {
+	Description string `json:"description" binding:"required"`
+}
+
+// GeneratePetActivityLog structures an owner's activity report and stores it
+// on the patient's timeline.
+func (h *Handler) GeneratePetActivityLog(c *gin.Context) {
+	var input ActivityInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, ok := patientID(c)
+	if !ok {
+		return
+	}
+	var patient models.Patient
+	if err := h.DB.First(&patient, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		return
+	}
+
+	activityLog, err := h.SoapService.GeneratePetActivityLog(input.Description, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	activityLog.PatientID = patient.ID
+	activityLog.Description = input.Description
+
+	// Concerns are escalated to the clinic as a follow-up task, stored
+	// together with the log
+	task, err := h.FollowUps.RecordActivity(activityLog, &patient)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if task != nil {
+		c.JSON(http.StatusCreated, gin.H{
+			"activity_log":   activityLog,
+			"follow_up_task": task,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"activity_log": activityLog,
+	})
+}
+
+// GetActivityTimeline lists a patient's activity logs, newest first.
+// Filters: type (comma-separated), from and to (dates), concerns=true for
+// flagged logs only, plus limit and offset.
+func (h *Handler) GetActivityTimeline(c *gin.Context) {
+	id, ok := patientID(c)
+	if !ok {
+		return
+	}
+	var patient models.Patient
+	if err := h.DB.First(&patient, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		return
+	}
+
+	query := h.DB.Model(&models.ActivityLog{}).Where("patient_id = ?", patient.ID)
+	if types := splitQuery(c.Query("type")); len(types) > 0 {
+		query = query.Where("activity_type IN ?", types)
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := parseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("occurred_at >= ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := parseEndDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("occurred_at <= ?", to)
+	}
+	if v := c.Query("concerns"); v != "" {
+		flagged, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "concerns must be true or false"})
+			return
+		}
+		query = query.Where("flagged = ?", flagged)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := pagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var activities []models.ActivityLog
+	if err := query.Order("occurred_at DESC, id DESC").Limit(limit).Offset(offset).Find(&activities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"activities": activities,
+		"total":      total,
+	})
+}
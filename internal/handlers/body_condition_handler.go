@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"vet-tails/ai/internal/models"
+	"vet-tails/ai/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EstimateBodyCondition scores body condition from side and top-view photos
+// ("images", labelled by repeated "views" fields) and stores the result on the
+// patient. "weight_kg" and "recorded_at" are optional.
+func (h *Handler) EstimateBodyCondition(c *gin.Context) {
+	files, err := h.formFiles(c, "images", "image")
+	if err != nil {
+		if errors.Is(err, errUploadTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Images exceed the %d byte upload limit", h.maxUploadSize())})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(files) > services.MaxBodyConditionImages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d images can be scored together", services.MaxBodyConditionImages)})
+		return
+	}
+
+	id, ok := patientID(c)
+	if !ok {
+		return
+	}
+	var patient models.Patient
+	if err := h.DB.First(&patient, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		return
+	}
+
+	var weight *float64
+	if v := c.PostForm("weight_kg"); v != "" {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil || w <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weight_kg must be a positive number"})
+			return
+		}
+		weight = &w
+	}
+	recordedAt := time.Now()
+	if v := c.PostForm("recorded_at"); v != "" {
+		t, err := parseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		recordedAt = t
+	}
+
+	prepared, err := h.prepareImages(files)
+	if err != nil {
+		c.JSON(imageStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	images := make([]string, len(prepared))
+	for i, image := range prepared {
+		images[i] = image.Base64
+	}
+
+	record, err := h.LlavaService.EstimateBodyCondition(images, c.PostFormArray("views"), patient.Species)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	record.PatientID = patient.ID
+	record.WeightKg = weight
+	record.RecordedAt = recordedAt
+
+	if err := h.DB.Create(record).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"body_condition": record,
+	})
+}
+
+// GetBodyConditionHistory returns a patient's body condition series, oldest
+// first, with a trend summary. "from" and "to" limit the date range.
+func (h *Handler) GetBodyConditionHistory(c *gin.Context) {
+	id, ok := patientID(c)
+	if !ok {
+		return
+	}
+	var patient models.Patient
+	if err := h.DB.First(&patient, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		return
+	}
+
+	query := h.DB.Where("patient_id = ?", patient.ID)
+	if v := c.Query("from"); v != "" {
+		from, err := parseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("recorded_at >= ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := parseEndDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("recorded_at <= ?", to)
+	}
+
+	var records []models.BodyConditionRecord
+	if err := query.Order("recorded_at ASC").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"records": records,
+		"trend":   models.BodyConditionTrendOf(records),
+	})
+}
+
+// latestWeight returns the most recently recorded weight for the patient,
+// from either a body condition record or the vital signs of a note, or 0 when
+// none is recorded.
+func (h *Handler) latestWeight(patientID uint) (float64, error) {
+	var weight float64
+	var at time.Time
+
+	var records []models.BodyConditionRecord
+	err := h.DB.Where("patient_id = ? AND weight_kg IS NOT NULL", patientID).
+		Order("recorded_at DESC").Limit(1).Find(&records).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(records) > 0 {
+		weight, at = *records[0].WeightKg, records[0].RecordedAt
+	}
+
+	var notes []models.Note
+	err = h.DB.Select("id", "objective", "created_at").Where("patient_id = ? AND created_at > ?", patientID, at).
+		Order("created_at DESC, id DESC").Find(&notes).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, n := range notes {
+		if w, ok := n.Objective.VitalSigns.WeightKg(); ok {
+			return w, nil
+		}
+	}
+	return weight, nil
+}
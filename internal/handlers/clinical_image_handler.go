@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"vet-tails/ai/internal/models"
+	"vet-tails/ai/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyzeClinicalImage analyses photos of a lesion, wound, ear cytology slide
+// or teeth ("type"). When "note_id" is given the result is attached to that
+// draft note's objective findings as a new version by "editor"; signed notes
+// are refused.
+func (h *Handler) AnalyzeClinicalImage(c *gin.Context) {
+	files, err := h.formFiles(c, "images", "image")
+	if err != nil {
+		if errors.Is(err, errUploadTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Images exceed the %d byte upload limit", h.maxUploadSize())})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(files) > services.MaxClinicalImages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d images can be analysed together", services.MaxClinicalImages)})
+		return
+	}
+
+	imageType := c.PostForm("type")
+	if !models.IsClinicalImageType(imageType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("type must be one of %v", models.ClinicalImageTypes)})
+		return
+	}
+
+	var note *models.Note
+	if v := c.PostForm("note_id"); v != "" {
+		noteID, err := parseID(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+			return
+		}
+		if note, err = h.Notes.GetNote(noteID); err != nil {
+			c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if note.IsSigned() {
+			c.JSON(http.StatusConflict, gin.H{"error": services.ErrNoteSigned.Error()})
+			return
+		}
+	}
+
+	prepared, err := h.prepareImages(files)
+	if err != nil {
+		c.JSON(imageStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	images := make([]string, len(prepared))
+	for i, image := range prepared {
+		images[i] = image.Base64
+	}
+
+	analysis, err := h.ClinicalImageService.Analyze(imageType, images, c.PostForm("body_site"), c.PostForm("notes"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if note != nil {
+		author := strings.TrimSpace(c.PostForm("editor"))
+		if author == "" {
+			author = models.NoteAuthorAI
+		}
+		if _, err := h.Notes.AttachImageFindings(note.ID, author, *analysis); err != nil {
+			c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"analysis": analysis,
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"vet-tails/ai/internal/formulary"
+	"vet-tails/ai/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DoseInput struct {
+	Drug string `json:"drug" binding:"required"`
+	// PatientID fills in species and the latest recorded weight when they
+	// are not given.
+	PatientID uint    `json:"patient_id"`
+	Species   string  `json:"species"`
+	WeightKg  float64 `json:"weight_kg"`
+	// Dose, Unit, Strength and Frequency describe a prescribed dose to
+	// check. Without Dose only the safe range is returned.
+	Dose      float64 `json:"dose"`
+	Unit      string  `json:"unit"`
+	Strength  string  `json:"strength"`
+	Frequency string  `json:"frequency"`
+}
+
+// CalculateDose computes the safe dose range of a drug for a patient's
+// species and weight, and checks a prescribed dose against it.
+func (h *Handler) CalculateDose(c *gin.Context) {
+	var input DoseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.PatientID != 0 {
+		var patient models.Patient
+		if err := h.DB.First(&patient, input.PatientID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		if input.Species == "" {
+			input.Species = patient.Species
+		}
+		if input.WeightKg == 0 {
+			weight, err := h.latestWeight(patient.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			input.WeightKg = weight
+		}
+	}
+	if input.Species == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "species or patient_id is required"})
+		return
+	}
+
+	result, err := formulary.CalculateDose(formulary.DoseRequest{
+		Drug:      input.Drug,
+		Species:   input.Species,
+		WeightKg:  input.WeightKg,
+		Dose:      input.Dose,
+		Unit:      input.Unit,
+		Strength:  input.Strength,
+		Frequency: input.Frequency,
+	})
+	if err != nil {
+		body := gin.H{"error": err.Error()}
+		// An unconvertible prescribed dose still leaves the safe range
+		if result != nil {
+			body["dose"] = result
+		}
+		c.JSON(doseStatus(err), body)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dose": result})
+}
+
+// doseStatus maps dose calculation errors to HTTP status codes.
+func doseStatus(err error) int {
+	switch {
+	case errors.Is(err, formulary.ErrInvalidWeight):
+		return http.StatusBadRequest
+	case errors.Is(err, formulary.ErrUnknownDrug), errors.Is(err, formulary.ErrNoSpeciesDose), errors.Is(err, formulary.ErrUnconvertedDose):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
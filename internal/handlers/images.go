@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"vet-tails/ai/internal/services"
+)
+
+// prepareImages decodes, validates and normalizes uploaded photos for the
+// vision model.
+func (h *Handler) prepareImages(files []*multipart.FileHeader) ([]*services.PreparedImage, error) {
+	prepared := make([]*services.PreparedImage, 0, len(files))
+	for _, file := range files {
+		src, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("error opening upload: %v", err)
+		}
+		data, err := io.ReadAll(src)
+		src.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading upload: %v", err)
+		}
+
+		image, err := services.PrepareImage(data, h.MaxImageDimension)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Filename, err)
+		}
+		prepared = append(prepared, image)
+	}
+	return prepared, nil
+}
+
+// imageStatus maps image preparation errors to HTTP status codes.
+func imageStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUnsupportedImage):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, services.ErrInvalidImage):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
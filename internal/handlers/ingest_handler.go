@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"vet-tails/ai/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IngestHandler bulk-loads documents into a collection from either an
+// uploaded ZIP archive ("archive") or a server-side directory ("directory")
+// located under the configured ingest root.
+func (h *Handler) IngestHandler(c *gin.Context) {
+	// Parse the body through the size limit before reading any form values.
+	file, err := h.formFile(c, "archive")
+	switch {
+	case errors.Is(err, errUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("archive exceeds the %d byte upload limit", h.maxUploadSize())})
+		return
+	// A directory request carries no archive, and may not be multipart at all
+	case err != nil && !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	collection := c.DefaultPostForm("collection", "vet_knowledge_base")
+	if file != nil {
+		h.ingestArchive(c, collection, file)
+		return
+	}
+
+	dir := c.PostForm("directory")
+	if dir == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide an archive file or a directory"})
+		return
+	}
+	if h.IngestRoot == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Directory ingestion is disabled"})
+		return
+	}
+	root, err := filepath.Abs(h.IngestRoot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	target := filepath.Join(root, filepath.Clean("/"+dir))
+	if rel, err := filepath.Rel(root, target); err != nil || strings.HasPrefix(rel, "..") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "directory must be inside the ingest root"})
+		return
+	}
+
+	report, err := h.KnowledgeBase.IngestDirectory(c.Request.Context(), collection, target)
+	if err != nil {
+		c.JSON(knowledgeBaseStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+func (h *Handler) ingestArchive(c *gin.Context, collection string, file *multipart.FileHeader) {
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".zip") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "archive must be a .zip file"})
+		return
+	}
+
+	tempPath, err := saveUpload(file, "ingest-*.zip")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+	defer os.Remove(tempPath)
+
+	report, err := h.KnowledgeBase.IngestZip(c.Request.Context(), collection, tempPath)
+	if errors.Is(err, services.ErrEmbedderMismatch) || errors.Is(err, services.ErrCollectionBusy) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"vet-tails/ai/internal/formulary"
+	"vet-tails/ai/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InteractionInput struct {
+	// Medications are the proposed drugs, as free text.
+	Medications []string `json:"medications" binding:"required"`
+	// PatientID adds the patient's current medications to the check.
+	PatientID uint `json:"patient_id"`
+	// CurrentMedications are checked alongside any the patient record lists.
+	CurrentMedications []string `json:"current_medications"`
+}
+
+// CheckInteractions checks proposed medications against each other and the
+// patient's current medications.
+func (h *Handler) CheckInteractions(c *gin.Context) {
+	var input InteractionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current := input.CurrentMedications
+	if input.PatientID != 0 {
+		if err := h.DB.First(&models.Patient{}, input.PatientID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		recorded, err := h.PatientSummaries.CurrentMedications(input.PatientID, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		current = append(current, recorded...)
+	}
+
+	alerts := formulary.CheckInteractions(current, input.Medications)
+	if alerts == nil {
+		alerts = []formulary.InteractionAlert{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"current_medications": current,
+		"interactions":        alerts,
+	})
+}
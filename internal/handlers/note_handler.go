@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"vet-tails/ai/internal/models"
+	"vet-tails/ai/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoteEditInput edits a draft note. Each section holds only the fields to
+// change, e.g. {"plan": {"follow_up": "recheck in 7 days"}}.
+type NoteEditInput struct {
+	// Editor identifies the veterinarian or staff member making the change.
+	Editor  string `json:"editor" binding:"required"`
+	Comment string `json:"comment"`
+	// Version is the version the edit was made against; when given, the
+	// edit is refused if someone else has changed the note since.
+	Version    int             `json:"version"`
+	Subjective json.RawMessage `json:"subjective"`
+	Objective  json.RawMessage `json:"objective"`
+	Assessment json.RawMessage `json:"assessment"`
+	Plan       json.RawMessage `json:"plan"`
+	// WeightKg is the weight to check doses against; when omitted the latest
+	// recorded weight is used.
+	WeightKg *float64 `json:"weight_kg"`
+}
+
+// NoteRegenerateInput asks for one section of a draft to be rewritten.
+type NoteRegenerateInput struct {
+	// Section is subjective, objective, assessment or plan.
+	Section string `json:"section" binding:"required"`
+	// Instructions guide the rewrite, e.g. "consider pancreatitis".
+	Instructions string `json:"instructions"`
+	// Editor identifies who asked for the rewrite.
+	Editor   string   `json:"editor" binding:"required"`
+	WeightKg *float64 `json:"weight_kg"`
+}
+
+type NoteSignInput struct {
+	Veterinarian string `json:"veterinarian" binding:"required"`
+	Comment      string `json:"comment"`
+	// OverrideReason acknowledges blocking safety warnings; a note with
+	// blocking warnings cannot be signed without one.
+	OverrideReason string `json:"override_reason"`
+}
+
+// noteStatus maps note errors to HTTP status codes.
+func noteStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNoteNotFound), errors.Is(err, services.ErrVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNoteSigned), errors.Is(err, services.ErrNoteConflict), errors.Is(err, services.ErrBlockingWarnings):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidEdit):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrNoTranscript):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrModelOutput):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+func noteID(c *gin.Context) (uint, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) GetNote(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+	note, err := h.Notes.GetNote(id)
+	if err != nil {
+		c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"note": note})
+}
+
+// UpdateNote edits a draft note and records the change as a new version.
+// The plan's safety is checked again against the patient. Signed notes
+// cannot be edited.
+func (h *Handler) UpdateNote(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+	var input NoteEditInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.WeightKg != nil && *input.WeightKg <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weight_kg must be a positive number"})
+		return
+	}
+
+	note, err := h.Notes.GetNote(id)
+	if err != nil {
+		c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if note.IsSigned() {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrNoteSigned.Error()})
+		return
+	}
+	patient, ok := h.patientContext(c, note.PatientID, input.WeightKg, note.ID)
+	if !ok {
+		return
+	}
+
+	note, changes, err := h.Notes.Edit(id, services.NoteEdit{
+		Editor:      strings.TrimSpace(input.Editor),
+		Comment:     input.Comment,
+		BaseVersion: input.Version,
+		Subjective:  input.Subjective,
+		Objective:   input.Objective,
+		Assessment:  input.Assessment,
+		Plan:        input.Plan,
+	}, patient)
+	if err != nil {
+		c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"note":            note,
+		"changes":         changes,
+		"safety_warnings": note.SafetyWarnings,
+		"blocking":        note.HasBlockingWarnings(),
+		"unsupported":     note.UnsupportedItems(),
+	})
+}
+
+// RegenerateNoteSection rewrites one section of a draft note from its
+// transcript, keeping the other sections, possibly vet-edited, as fixed
+// context. The result is recorded as a new version and the plan's safety is
+// checked again.
+func (h *Handler) RegenerateNoteSection(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+	var input NoteRegenerateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.WeightKg != nil && *input.WeightKg <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weight_kg must be a positive number"})
+		return
+	}
+
+	note, err := h.Notes.GetNote(id)
+	if err != nil {
+		c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if note.IsSigned() {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrNoteSigned.Error()})
+		return
+	}
+	patient, ok := h.patientContext(c, note.PatientID, input.WeightKg, note.ID)
+	if !ok {
+		return
+	}
+
+	section := strings.ToLower(strings.TrimSpace(input.Section))
+	note, changes, err := h.Notes.Regenerate(id, section, input.Instructions, strings.TrimSpace(input.Editor), patient)
+	if err != nil {
+		c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"note":            note,
+		"section":         section,
+		"changes":         changes,
+		"safety_warnings": note.SafetyWarnings,
+		"blocking":        note.HasBlockingWarnings(),
+		"unsupported":     note.UnsupportedItems(),
+	})
+}
+
+// GetNoteVersions returns a note's version history, oldest first.
+func (h *Handler) GetNoteVersions(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+	versions, err := h.Notes.Versions(id)
+	if err != nil {
+		c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// GetNoteDiff compares two versions of a note, field by field. By default it
+// compares the generated draft with the latest version; from and to pick
+// other versions.
+func (h *Handler) GetNoteDiff(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+	var from, to int
+	for name, v := range map[string]*int{"from": &from, "to": &to} {
+		if q := c.Query(name); q != "" {
+			n, err := strconv.Atoi(q)
+			if err != nil || n <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a version number"})
+				return
+			}
+			*v = n
+		}
+	}
+
+	a, b, changes, err := h.Notes.Diff(id, from, to)
+	if err != nil {
+		c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":    versionSummary(a),
+		"to":      versionSummary(b),
+		"changes": changes,
+	})
+}
+
+// SignNote locks a draft note, recording the signing veterinarian and time.
+// Notes with blocking safety warnings are refused with 409 unless an
+// override_reason is given.
+func (h *Handler) SignNote(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+	var input NoteSignInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	note, err := h.Notes.Sign(id, strings.TrimSpace(input.Veterinarian), input.Comment, input.OverrideReason)
+	if err != nil {
+		c.JSON(noteStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"note": note})
+}
+
+// versionSummary describes a version without its content.
+func versionSummary(v *models.NoteVersion) gin.H {
+	return gin.H{
+		"version":    v.Version,
+		"action":     v.Action,
+		"author":     v.Author,
+		"created_at": v.CreatedAt,
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page sizes for list endpoints.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// parseDate accepts RFC 3339 timestamps or plain YYYY-MM-DD dates.
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", v)
+	}
+	return t, nil
+}
+
+// parseEndDate parses the upper bound of a date range. A plain date includes
+// the whole day.
+func parseEndDate(v string) (time.Time, error) {
+	t, err := parseDate(v)
+	if err == nil && len(v) == len("2006-01-02") {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, err
+}
+
+// pagination reads the limit and offset query parameters.
+func pagination(c *gin.Context) (limit int, offset int, err error) {
+	limit = defaultPageSize
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return 0, 0, fmt.Errorf("limit must be a positive number")
+		}
+		limit = min(limit, maxPageSize)
+	}
+	if v := c.Query("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must be zero or a positive number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// parseID parses a record ID from a path, query or form value. IDs must be
+// parsed before they reach gorm, which treats a string condition as SQL.
+func parseID(v string) (uint, error) {
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid ID %q", v)
+	}
+	return uint(id), nil
+}
+
+// patientID reads the patient ID path parameter, responding with 400 when it
+// is not a valid ID.
+func patientID(c *gin.Context) (uint, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"vet-tails/ai/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PatientInput struct {
+	Name     *string `json:"name"`
+	Species  *string `json:"species"`
+	Breed    *string `json:"breed"`
+	ClinicID *string `json:"clinic_id"`
+	// DateOfBirth is YYYY-MM-DD or RFC 3339.
+	DateOfBirth *string `json:"date_of_birth"`
+}
+
+type AllergyInput struct {
+	// AllergyID links an existing allergy; otherwise one is found or created
+	// by Name.
+	AllergyID uint   `json:"allergy_id"`
+	Name      string `json:"name"`
+	// Severity and Description apply to this patient only.
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// apply copies the fields present in the input onto the patient.
+func (in *PatientInput) apply(p *models.Patient) error {
+	if in.Name != nil {
+		p.Name = strings.TrimSpace(*in.Name)
+	}
+	if in.Species != nil {
+		p.Species = strings.ToLower(strings.TrimSpace(*in.Species))
+	}
+	if in.Breed != nil {
+		p.Breed = strings.TrimSpace(*in.Breed)
+	}
+	if in.ClinicID != nil {
+		p.ClinicID = strings.TrimSpace(*in.ClinicID)
+	}
+	if in.DateOfBirth != nil {
+		if *in.DateOfBirth == "" {
+			p.DateOfBirth = time.Time{}
+		} else {
+			dob, err := parseDate(*in.DateOfBirth)
+			if err != nil {
+				return err
+			}
+			if dob.After(time.Now()) {
+				return errors.New("date_of_birth is in the future")
+			}
+			p.DateOfBirth = dob
+		}
+	}
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+// loadPatient fetches the patient named by the :id parameter with its
+// allergies, writing a 400 for an invalid ID and a 404 when it does not
+// exist.
+func (h *Handler) loadPatient(c *gin.Context) (*models.Patient, bool) {
+	id, ok := patientID(c)
+	if !ok {
+		return nil, false
+	}
+	var patient models.Patient
+	if err := h.DB.Preload("Allergies.Allergy").First(&patient, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return &patient, true
+}
+
+func (h *Handler) CreatePatient(c *gin.Context) {
+	var input PatientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var patient models.Patient
+	if err := input.apply(&patient); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.DB.Create(&patient).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"patient": patient})
+}
+
+func (h *Handler) GetPatient(c *gin.Context) {
+	patient, ok := h.loadPatient(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"patient": patient})
+}
+
+// UpdatePatient changes only the fields present in the request body.
+func (h *Handler) UpdatePatient(c *gin.Context) {
+	patient, ok := h.loadPatient(c)
+	if !ok {
+		return
+	}
+
+	var input PatientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.apply(patient); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.DB.Model(patient).
+		Select("name", "species", "breed", "clinic_id", "date_of_birth").
+		Updates(patient).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"patient": patient})
+}
+
+// ListPatients lists patients by name. q searches names; species, breed and
+// clinic_id filter the results.
+func (h *Handler) ListPatients(c *gin.Context) {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.Model(&models.Patient{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLike(q)+"%")
+	}
+	if species := strings.TrimSpace(c.Query("species")); species != "" {
+		query = query.Where("LOWER(species) = ?", strings.ToLower(species))
+	}
+	if breed := strings.TrimSpace(c.Query("breed")); breed != "" {
+		// Matches mixes too, e.g. "Labrador" finds "Labrador Retriever mix"
+		query = query.Where("breed ILIKE ?", "%"+escapeLike(breed)+"%")
+	}
+	if clinicID := c.Query("clinic_id"); clinicID != "" {
+		query = query.Where("clinic_id = ?", clinicID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var patients []models.Patient
+	if err := query.Preload("Allergies.Allergy").Order("name ASC, id ASC").Limit(limit).Offset(offset).Find(&patients).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"patients": patients,
+		"total":    total,
+	})
+}
+
+// GetPatientHistory returns the patient's notes in chronological order.
+// from and to limit the date range.
+func (h *Handler) GetPatientHistory(c *gin.Context) {
+	patient, ok := h.loadPatient(c)
+	if !ok {
+		return
+	}
+
+	query := h.DB.Where("patient_id = ?", patient.ID)
+	if v := c.Query("from"); v != "" {
+		from, err := parseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := parseEndDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("created_at <= ?", to)
+	}
+
+	var notes []models.Note
+	if err := query.Order("created_at ASC, id ASC").Find(&notes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"patient": patient,
+		"notes":   notes,
+	})
+}
+
+func (h *Handler) ListAllergies(c *gin.Context) {
+	var allergies []models.Allergy
+	if err := h.DB.Order("name ASC").Find(&allergies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"allergies": allergies})
+}
+
+func (h *Handler) GetPatientAllergies(c *gin.Context) {
+	patient, ok := h.loadPatient(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"allergies": patient.Allergies})
+}
+
+// AddPatientAllergy records an allergy for the patient, creating the allergen
+// by name if it is not known yet. Severity and description are kept per
+// patient; when omitted they default to the allergen's. Adding an allergy the
+// patient already has updates its severity and description.
+func (h *Handler) AddPatientAllergy(c *gin.Context) {
+	patient, ok := h.loadPatient(c)
+	if !ok {
+		return
+	}
+
+	var input AllergyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	severity := strings.TrimSpace(input.Severity)
+	description := strings.TrimSpace(input.Description)
+
+	var allergy models.Allergy
+	switch {
+	case input.AllergyID != 0:
+		if err := h.DB.First(&allergy, input.AllergyID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Allergy not found"})
+			return
+		}
+	case strings.TrimSpace(input.Name) != "":
+		name := strings.TrimSpace(input.Name)
+		err := h.DB.Where("LOWER(name) = ?", strings.ToLower(name)).
+			Attrs(models.Allergy{Name: name, Severity: severity, Description: description}).
+			FirstOrCreate(&allergy).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide allergy_id or name"})
+		return
+	}
+
+	for i := range patient.Allergies {
+		existing := &patient.Allergies[i]
+		if existing.AllergyID != allergy.ID {
+			continue
+		}
+		if severity != "" {
+			existing.Severity = severity
+		}
+		if description != "" {
+			existing.Description = description
+		}
+		err := h.DB.Model(existing).
+			Select("severity", "description").
+			Updates(existing).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"allergies": patient.Allergies})
+		return
+	}
+
+	record := models.PatientAllergy{
+		PatientID:   patient.ID,
+		AllergyID:   allergy.ID,
+		Allergy:     allergy,
+		Severity:    allergy.Severity,
+		Description: allergy.Description,
+	}
+	if severity != "" {
+		record.Severity = severity
+	}
+	if description != "" {
+		record.Description = description
+	}
+	if err := h.DB.Omit("Allergy").Create(&record).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	patient.Allergies = append(patient.Allergies, record)
+
+	c.JSON(http.StatusOK, gin.H{"allergies": patient.Allergies})
+}
+
+func (h *Handler) RemovePatientAllergy(c *gin.Context) {
+	patient, ok := h.loadPatient(c)
+	if !ok {
+		return
+	}
+
+	allergyID, err := parseID(c.Param("allergyId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid allergy ID"})
+		return
+	}
+	var allergy models.Allergy
+	if err := h.DB.First(&allergy, allergyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Allergy not found"})
+		return
+	}
+	err = h.DB.Where("patient_id = ? AND allergy_id = ?", patient.ID, allergy.ID).
+		Delete(&models.PatientAllergy{}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	remaining := patient.Allergies[:0]
+	for _, a := range patient.Allergies {
+		if a.AllergyID != allergy.ID {
+			remaining = append(remaining, a)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"allergies": remaining})
+}
+
+// escapeLike escapes LIKE wildcards in user input.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
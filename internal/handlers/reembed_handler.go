@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"vet-tails/ai/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReembedInput struct {
+	Collection string `json:"collection" binding:"required"`
+	Model      string `json:"model" binding:"required"`
+	Dimension  int    `json:"dimension" binding:"required,gt=0"`
+}
+
+// StartReembed migrates a collection to a new embedding model in the
+// background. Once the job completes the collection is queried and ingested
+// with the new model, whatever the configured embedder. A second job on the
+// same collection is refused with 409, and so is ingestion into the
+// collection while the job runs.
+func (h *Handler) StartReembed(c *gin.Context) {
+	var input ReembedInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, err := services.NewEmbedder(h.OllamaURL, input.Model, input.Dimension, h.EmbeddingCache)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.KnowledgeBase.StartReembed(input.Collection, target)
+	if err != nil {
+		c.JSON(knowledgeBaseStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job": job})
+}
+
+func (h *Handler) GetReembedJob(c *gin.Context) {
+	job, ok := h.KnowledgeBase.ReembedJob(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Re-embed job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+func (h *Handler) GetEmbeddingCacheStats(c *gin.Context) {
+	if h.EmbeddingCache == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Embedding cache is disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stats": h.EmbeddingCache.Stats()})
+}
@@ -1,11 +1,12 @@
 package handlers
 
 import (
-	"encoding/base64"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"vet-tails/ai/internal/models"
 	"vet-tails/ai/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +16,12 @@ import (
 type Input struct {
 	PatientID  uint   `json:"patient_id"`
 	Transcript string `json:"transcript"`
-	Summary    string `json:"summary"`
+	// WeightKg is today's weight for dose checks; when omitted the latest
+	// recorded weight is used.
+	WeightKg *float64 `json:"weight_kg"`
+	// ImageFindings are clinical image analyses to attach to the objective
+	// section of the generated note.
+	ImageFindings []models.ClinicalImageAnalysis `json:"image_findings"`
 }
 
 type Output struct {
@@ -27,11 +33,41 @@ type BreedOutput struct {
 }
 
 type Handler struct {
-	DB           *gorm.DB
-	LlavaService *services.LlavaService
-	SoapService  *services.SOAPService
+	DB                   *gorm.DB
+	LlavaService         *services.LlavaService
+	ClinicalImageService *services.ClinicalImageService
+	PatientSummaries     *services.PatientSummaryService
+	FollowUps            *services.FollowUpService
+	SoapService          *services.SOAPService
+	Notes                *services.NoteService
+	KnowledgeBase        *services.KnowledgeBaseService
+	// EmbeddingCache is shared by every embedder the handler creates.
+	EmbeddingCache *services.EmbeddingCache
+	OllamaURL      string
+	IngestRoot     string
+	// MaxUploadSize caps multipart request bodies in bytes.
+	MaxUploadSize int64
+	// MaxImageDimension caps the longest side of images sent to the vision
+	// model.
+	MaxImageDimension int
 }
 
+// knowledgeBaseStatus maps knowledge-base errors to HTTP status codes.
+func knowledgeBaseStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrEmbedderMismatch), errors.Is(err, services.ErrCollectionBusy):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrReembedUnchanged):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// CreateSOAPNote generates a SOAP note from a transcript. When patient_id is
+// given the plan is checked against the patient's allergies, species and
+// weight, and the note is stored in the patient's history as a draft for the
+// vet to review and sign. Blocking safety warnings do not stop the note from
+// being stored; they are recorded on it for the vet to resolve.
 func (h *Handler) CreateSOAPNote(c *gin.Context) {
 
 	var input Input
@@ -41,87 +77,161 @@ func (h *Handler) CreateSOAPNote(c *gin.Context) {
 		return
 	}
 
-	note, err := h.SoapService.GenerateSOAPNote(input.Transcript)
+	if input.WeightKg != nil && *input.WeightKg <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weight_kg must be a positive number"})
+		return
+	}
+
+	var patient *services.PatientContext
+	if input.PatientID != 0 {
+		var ok bool
+		if patient, ok = h.patientContext(c, input.PatientID, input.WeightKg, 0); !ok {
+			return
+		}
+	}
+
+	note, err := h.SoapService.GenerateSOAPNote(input.Transcript, patient)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	note.AttachImageFindings(input.ImageFindings...)
+
+	if input.PatientID != 0 {
+		note.PatientID = input.PatientID
+		if err := h.Notes.Create(note); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"soap_note": note,
+		"soap_note":       note,
+		"safety_warnings": note.SafetyWarnings,
+		"blocking":        note.HasBlockingWarnings(),
+		"unsupported":     note.UnsupportedItems(),
 	})
 
 }
 
-func (h *Handler) GeneratePatientSummary(c *gin.Context) {
-	var input Input
+// patientContext loads what note generation and editing check the plan
+// against. Without weightKg the latest recorded weight is used. Medications
+// from the note exceptNoteID are not counted as current.
+func (h *Handler) patientContext(c *gin.Context, patientID uint, weightKg *float64, exceptNoteID uint) (*services.PatientContext, bool) {
+	patient := &services.PatientContext{Patient: &models.Patient{}}
+	if err := h.DB.Preload("Allergies.Allergy").First(patient.Patient, patientID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		return nil, false
+	}
+	if weightKg != nil {
+		patient.WeightKg = *weightKg
+	} else {
+		weight, err := h.latestWeight(patientID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		patient.WeightKg = weight
+	}
+	current, err := h.PatientSummaries.CurrentMedications(patientID, exceptNoteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	patient.CurrentMedications = current
+	return patient, true
+}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// GeneratePatientSummary summarizes a patient's stored history. The summary
+// is cached until new history arrives; ?refresh=true regenerates it anyway.
+func (h *Handler) GeneratePatientSummary(c *gin.Context) {
+	id, ok := patientID(c)
+	if !ok {
 		return
 	}
 
-	summary, err := h.SoapService.GeneratePatientSummary(input.Summary)
+	summary, cached, err := h.PatientSummaries.Summarize(id, c.Query("refresh") == "true")
 	if err != nil {
+		if errors.Is(err, services.ErrPatientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"summary": summary,
+		"cached":  cached,
 	})
 }
 
+// DetectBreed accepts one or more photos of the same animal as "images"
+// (or a single "image"), optionally labelled by repeated "views" fields.
 func (h *Handler) DetectBreed(c *gin.Context) {
 
-	file, err := c.FormFile("image")
+	files, err := h.formFiles(c, "images", "image")
 	if err != nil {
+		if errors.Is(err, errUploadTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Images exceed the %d byte upload limit", h.maxUploadSize())})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	image, err := file.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	// Read the file content
-	fileBytes, err := io.ReadAll(image)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if len(files) > services.MaxBreedImages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d images can be analysed together", services.MaxBreedImages)})
 		return
 	}
 
-	// Convert to base64 string
-	base64String := base64.StdEncoding.EncodeToString(fileBytes)
+	var patient *models.Patient
+	if v := c.PostForm("patient_id"); v != "" {
+		patientID, err := parseID(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+			return
+		}
+		patient = &models.Patient{}
+		if err := h.DB.First(patient, patientID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+	}
 
-	breed, err := h.LlavaService.DetectBreed(base64String)
+	prepared, err := h.prepareImages(files)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(imageStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"breed": breed,
-	})
-}
-
-func (h *Handler) GeneratePetActivityLog(c *gin.Context) {
-	var input Input
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	images := make([]string, len(prepared))
+	for i, image := range prepared {
+		images[i] = image.Base64
 	}
 
-	activityLog, err := h.SoapService.GeneratePetActivityLog(input.Transcript)
+	breed, err := h.LlavaService.DetectBreed(images, c.PostFormArray("views"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Optionally record the consensus on the patient
+	if patient != nil {
+		patient.ApplyBreedDetection(&breed.BreedDetection)
+		if err := h.DB.Save(patient).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"breed":   breed,
+			"images":  prepared,
+			"patient": patient,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"activity_log": activityLog,
+		"breed":  breed,
+		"images": prepared,
 	})
 }
 
@@ -131,24 +241,40 @@ type UploadPDFInput struct {
 
 func (h *Handler) UploadPDFHandler(c *gin.Context) {
 
-	file, err := c.FormFile("pdf")
+	file, err := h.formFile(c, "pdf")
 	if err != nil {
+		if errors.Is(err, errUploadTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("PDF exceeds the %d byte upload limit", h.maxUploadSize())})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No PDF file provided"})
 		return
 	}
 
-	// Save the uploaded file temporarily
-	tempPath := fmt.Sprintf("/tmp/%s", file.Filename)
-	if err := c.SaveUploadedFile(file, tempPath); err != nil {
+	// Save the uploaded file under a unique temporary name
+	tempPath, err := saveUpload(file, "upload-*.pdf")
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 		return
 	}
 	defer os.Remove(tempPath) // Clean up after processing
 
+	if err := services.ValidatePDF(tempPath); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotPDF):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEncryptedPDF), errors.Is(err, services.ErrMalformedPDF):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
 	// Add to ChromaDB
-	err = services.AddDocuments(c.Request.Context(), "clinic-1", tempPath)
+	err = h.KnowledgeBase.AddDocuments(c.Request.Context(), "clinic-1", tempPath, filepath.Base(file.Filename))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(knowledgeBaseStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -156,7 +282,7 @@ func (h *Handler) UploadPDFHandler(c *gin.Context) {
 }
 
 func (h *Handler) GetCollection(c *gin.Context) {
-	collection := services.GetCollection(c.Request.Context(), "vet_knowledge_base")
+	collection := h.KnowledgeBase.GetCollection(c.Request.Context(), "vet_knowledge_base")
 	c.JSON(http.StatusOK, gin.H{
 		"collection": collection,
 	})
@@ -172,9 +298,9 @@ func (h *Handler) CreateCollection(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	res, err := services.CreateCollection(c.Request.Context(), input.Name)
+	res, err := h.KnowledgeBase.CreateCollection(c.Request.Context(), input.Name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(knowledgeBaseStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -196,9 +322,9 @@ func (h *Handler) QueryChromaDB(c *gin.Context) {
 		return
 	}
 
-	response, err := services.QueryChromaDB(c.Request.Context(), input.Query, input.CollectionName, input.NResults)
+	response, err := h.KnowledgeBase.QueryChromaDB(c.Request.Context(), input.Query, input.CollectionName, input.NResults)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(knowledgeBaseStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -213,9 +339,9 @@ func (h *Handler) SearchKnowledgeBase(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	response, err := services.SearchKnowledgeBase(c.Request.Context(), input.CollectionName, input.Query)
+	response, err := h.KnowledgeBase.SearchKnowledgeBase(c.Request.Context(), input.CollectionName, input.Query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(knowledgeBaseStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
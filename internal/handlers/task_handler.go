@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"vet-tails/ai/internal/models"
+	"vet-tails/ai/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaskActionInput struct {
+	// Actor identifies the staff member acting on the task.
+	Actor string `json:"actor" binding:"required"`
+	Note  string `json:"note"`
+}
+
+// taskStatus maps follow-up errors to HTTP status codes.
+func taskStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTransition):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// ListFollowUpTasks returns the follow-up queue, most pressing first.
+// Filters: clinic_id, patient_id, status and severity (comma-separated). By
+// default only unresolved tasks are listed; pass an empty status for all.
+func (h *Handler) ListFollowUpTasks(c *gin.Context) {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := services.TaskFilter{
+		ClinicID: c.Query("clinic_id"),
+		Status:   splitQuery(c.DefaultQuery("status", models.TaskStatusOpen+","+models.TaskStatusAcknowledged)),
+		Severity: splitQuery(c.Query("severity")),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	if v := c.Query("patient_id"); v != "" {
+		id, err := parseID(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+			return
+		}
+		filter.PatientID = id
+	}
+
+	tasks, total, err := h.FollowUps.ListTasks(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"tasks": tasks,
+		"total": total,
+	})
+}
+
+// taskID reads the task ID path parameter, responding with 400 when it is not
+// a valid ID.
+func taskID(c *gin.Context) (uint, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// GetFollowUpTask returns a task with its audit trail.
+func (h *Handler) GetFollowUpTask(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	task, err := h.FollowUps.GetTask(id)
+	if err != nil {
+		c.JSON(taskStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+func (h *Handler) AcknowledgeFollowUpTask(c *gin.Context) {
+	h.updateFollowUpTask(c, h.FollowUps.Acknowledge)
+}
+
+func (h *Handler) ResolveFollowUpTask(c *gin.Context) {
+	h.updateFollowUpTask(c, h.FollowUps.Resolve)
+}
+
+func (h *Handler) updateFollowUpTask(c *gin.Context, update func(id uint, actor string, note string) (*models.FollowUpTask, error)) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	var input TaskActionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := update(id, strings.TrimSpace(input.Actor), input.Note)
+	if err != nil {
+		c.JSON(taskStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// splitQuery splits a comma-separated query value, dropping empty items.
+func splitQuery(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
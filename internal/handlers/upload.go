@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxUploadSize is used when no upload limit is configured.
+const DefaultMaxUploadSize = 20 << 20
+
+var errUploadTooLarge = errors.New("upload exceeds the maximum allowed size")
+
+func (h *Handler) maxUploadSize() int64 {
+	if h.MaxUploadSize > 0 {
+		return h.MaxUploadSize
+	}
+	return DefaultMaxUploadSize
+}
+
+// formFile reads a multipart file field with the request body capped at the
+// configured upload size.
+func (h *Handler) formFile(c *gin.Context, field string) (*multipart.FileHeader, error) {
+	limit := h.maxUploadSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+	file, err := c.FormFile(field)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, errUploadTooLarge
+		}
+		return nil, err
+	}
+	if file.Size > limit {
+		return nil, errUploadTooLarge
+	}
+	return file, nil
+}
+
+// formFiles collects the files uploaded under any of the given multipart
+// fields, in field order, with the request body capped at the configured
+// upload size.
+func (h *Handler) formFiles(c *gin.Context, fields ...string) ([]*multipart.FileHeader, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadSize())
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, errUploadTooLarge
+		}
+		return nil, err
+	}
+	var files []*multipart.FileHeader
+	for _, field := range fields {
+		files = append(files, form.File[field]...)
+	}
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	return files, nil
+}
+
+// saveUpload streams an uploaded file into a new uniquely named temp file and
+// returns its path. The caller is responsible for removing it.
+func saveUpload(file *multipart.FileHeader, pattern string) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("error opening upload: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("error creating temp file: %v", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("error writing temp file: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("error writing temp file: %v", err)
+	}
+	return dst.Name(), nil
+}
@@ -1,14 +1,29 @@
 package models
 
+import (
+	"strings"
+	"time"
+)
+
 type ActivityLog struct {
-	ActivityType   string   `json:"activity_type"`
+	ID        uint `json:"id" gorm:"primaryKey"`
+	PatientID uint `json:"patient_id" gorm:"index"`
+	// Description is the owner's original report.
+	Description    string   `json:"description"`
+	ActivityType   string   `json:"activity_type" gorm:"index"`
 	Timestamp      string   `json:"timestamp"`
 	Duration       string   `json:"duration"`
-	Details        Details  `json:"details"`
-	Observations   []string `json:"observations"`
-	Concerns       []string `json:"concerns"`
+	Details        Details  `json:"details" gorm:"serializer:json"`
+	Observations   []string `json:"observations" gorm:"serializer:json"`
+	Concerns       []string `json:"concerns" gorm:"serializer:json"`
 	FollowUpNeeded bool     `json:"follow_up_needed"`
 	Notes          string   `json:"notes"`
+	// OccurredAt is Timestamp parsed, or the submission time when the model
+	// could not date the activity.
+	OccurredAt time.Time `json:"occurred_at" gorm:"index"`
+	// Flagged is set when the log raises concerns or asks for follow-up.
+	Flagged   bool      `json:"flagged" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Details struct {
@@ -16,3 +31,37 @@ type Details struct {
 	IntensityLevel string `json:"intensity_level"`
 	Location       string `json:"location"`
 }
+
+var activityTimestampLayouts = []string{
+	"2006-01-02 15:04",
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// Normalize prepares a generated activity log for storage: the activity type
+// is lower-cased, the timestamp is parsed into OccurredAt and empty concerns
+// are dropped before Flagged is derived.
+func (a *ActivityLog) Normalize(submittedAt time.Time) {
+	a.ActivityType = strings.ToLower(strings.TrimSpace(a.ActivityType))
+
+	a.OccurredAt = submittedAt
+	for _, layout := range activityTimestampLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(a.Timestamp), submittedAt.Location()); err == nil {
+			// Reports are about the past; a future date is a misread.
+			if !t.After(submittedAt) {
+				a.OccurredAt = t
+			}
+			break
+		}
+	}
+
+	concerns := a.Concerns[:0]
+	for _, c := range a.Concerns {
+		if c = strings.TrimSpace(c); c != "" && !strings.EqualFold(c, "none") {
+			concerns = append(concerns, c)
+		}
+	}
+	a.Concerns = concerns
+	a.Flagged = len(a.Concerns) > 0 || a.FollowUpNeeded
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Body condition categories on the 9-point scale.
+const (
+	BodyConditionUnderweight = "underweight"
+	BodyConditionIdeal       = "ideal"
+	BodyConditionOverweight  = "overweight"
+	BodyConditionObese       = "obese"
+)
+
+// BodyConditionRecord is one body condition score (BCS) measurement on the
+// 9-point scale. Records form a time series per patient.
+type BodyConditionRecord struct {
+	ID         uint                    `json:"id" gorm:"primaryKey"`
+	PatientID  uint                    `json:"patient_id" gorm:"index"`
+	Score      int                     `json:"score"`
+	Category   string                  `json:"category"`
+	Confidence float64                 `json:"confidence"`
+	Reasoning  string                  `json:"reasoning"`
+	Indicators BodyConditionIndicators `json:"indicators" gorm:"serializer:json"`
+	// WeightKg is the weight measured at the same visit, if any.
+	WeightKg   *float64  `json:"weight_kg"`
+	Views      []string  `json:"views" gorm:"serializer:json"`
+	Model      string    `json:"model"`
+	RecordedAt time.Time `json:"recorded_at" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BodyConditionIndicators are the visual cues behind a score.
+type BodyConditionIndicators struct {
+	RibVisibility  string   `json:"rib_visibility"`
+	WaistFromAbove string   `json:"waist_from_above"`
+	AbdominalTuck  string   `json:"abdominal_tuck"`
+	FatDeposits    []string `json:"fat_deposits"`
+}
+
+// BodyConditionCategory maps a 9-point score to its category.
+func BodyConditionCategory(score int) string {
+	switch {
+	case score <= 3:
+		return BodyConditionUnderweight
+	case score <= 5:
+		return BodyConditionIdeal
+	case score <= 7:
+		return BodyConditionOverweight
+	}
+	return BodyConditionObese
+}
+
+// Normalize clamps the score to the 9-point scale and derives its category.
+func (r *BodyConditionRecord) Normalize() {
+	if r.Score < 1 {
+		r.Score = 1
+	}
+	if r.Score > 9 {
+		r.Score = 9
+	}
+	r.Category = BodyConditionCategory(r.Score)
+	r.Confidence = unitInterval(r.Confidence)
+}
+
+// BodyConditionTrend summarizes a patient's body condition series.
+type BodyConditionTrend struct {
+	Count       int        `json:"count"`
+	FirstScore  int        `json:"first_score"`
+	LatestScore int        `json:"latest_score"`
+	ScoreChange int        `json:"score_change"`
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+	// WeightChangeKg compares the first and latest records that have a weight.
+	WeightChangeKg *float64 `json:"weight_change_kg,omitempty"`
+	// WeightChangePercent is WeightChangeKg relative to the first weight.
+	WeightChangePercent *float64 `json:"weight_change_percent,omitempty"`
+	Direction           string   `json:"direction"`
+}
+
+// Trend directions.
+const (
+	TrendStable     = "stable"
+	TrendIncreasing = "increasing"
+	TrendDecreasing = "decreasing"
+)
+
+// BodyConditionTrendOf summarizes records ordered by RecordedAt.
+func BodyConditionTrendOf(records []BodyConditionRecord) BodyConditionTrend {
+	trend := BodyConditionTrend{Count: len(records), Direction: TrendStable}
+	if len(records) == 0 {
+		return trend
+	}
+	first, latest := records[0], records[len(records)-1]
+	trend.FirstScore = first.Score
+	trend.LatestScore = latest.Score
+	trend.ScoreChange = latest.Score - first.Score
+	trend.From = &first.RecordedAt
+	trend.To = &latest.RecordedAt
+
+	var firstWeight, latestWeight *float64
+	for i := range records {
+		if records[i].WeightKg == nil {
+			continue
+		}
+		if firstWeight == nil {
+			firstWeight = records[i].WeightKg
+		}
+		latestWeight = records[i].WeightKg
+	}
+	if firstWeight != nil && latestWeight != nil && latestWeight != firstWeight {
+		change := math.Round((*latestWeight-*firstWeight)*100) / 100
+		trend.WeightChangeKg = &change
+		if *firstWeight > 0 {
+			percent := math.Round(change / *firstWeight * 1000) / 10
+			trend.WeightChangePercent = &percent
+		}
+	}
+
+	switch {
+	case trend.ScoreChange > 0:
+		trend.Direction = TrendIncreasing
+	case trend.ScoreChange < 0:
+		trend.Direction = TrendDecreasing
+	case trend.WeightChangePercent != nil && *trend.WeightChangePercent >= 5:
+		trend.Direction = TrendIncreasing
+	case trend.WeightChangePercent != nil && *trend.WeightChangePercent <= -5:
+		trend.Direction = TrendDecreasing
+	}
+	return trend
+}
@@ -1,9 +1,290 @@
 package models
 
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
 type Breed struct {
 	Breed string `json:"breed"`
 }
 
+// Species values reported by breed detection.
+const (
+	SpeciesDog    = "dog"
+	SpeciesCat    = "cat"
+	SpeciesRabbit = "rabbit"
+	SpeciesOther  = "other"
+)
+
 type BreedDetection struct {
-	Breed string `json:"breed"`
+	Species               string                 `json:"species"`
+	SpeciesConfidence     float64                `json:"species_confidence"`
+	PrimaryBreed          string                 `json:"primary_breed"`
+	MixedBreed            bool                   `json:"mixed_breed"`
+	Confidence            float64                `json:"confidence"`
+	Candidates            []BreedCandidate       `json:"candidates"`
+	VisibleFeatures       []string               `json:"visible_features"`
+	HealthPredispositions []HealthPredisposition `json:"health_predispositions"`
+}
+
+// BreedCandidate is one breed contributing to the animal's appearance.
+// Proportion is the estimated share of the breed in a mix; Confidence is how
+// sure the model is that the breed is present at all.
+type BreedCandidate struct {
+	Breed      string  `json:"breed"`
+	Proportion float64 `json:"proportion"`
+	Confidence float64 `json:"confidence"`
+}
+
+type HealthPredisposition struct {
+	Condition      string   `json:"condition"`
+	AssociatedWith []string `json:"associated_with"`
+	Risk           string   `json:"risk"`
+	Notes          string   `json:"notes"`
+}
+
+// mixedBreedThreshold is the smallest proportion for a secondary breed to make
+// the animal count as a mix.
+const mixedBreedThreshold = 0.1
+
+// Normalize calibrates a raw model answer: confidences and proportions are
+// mapped onto 0–1 (models often answer in percent), proportions are rescaled
+// to sum to 1, candidates are ranked by proportion and species is reduced to
+// one of the known values.
+func (d *BreedDetection) Normalize() {
+	d.Species = normalizeSpecies(d.Species)
+	d.SpeciesConfidence = unitInterval(d.SpeciesConfidence)
+	d.Confidence = unitInterval(d.Confidence)
+
+	candidates := d.Candidates[:0]
+	total := 0.0
+	for _, c := range d.Candidates {
+		c.Breed = strings.TrimSpace(c.Breed)
+		if c.Breed == "" {
+			continue
+		}
+		c.Proportion = unitInterval(c.Proportion)
+		c.Confidence = unitInterval(c.Confidence)
+		total += c.Proportion
+		candidates = append(candidates, c)
+	}
+	if len(candidates) == 0 && d.PrimaryBreed != "" {
+		candidates = append(candidates, BreedCandidate{Breed: d.PrimaryBreed, Proportion: 1, Confidence: d.Confidence})
+		total = 1
+	}
+	for i := range candidates {
+		if total > 0 {
+			candidates[i].Proportion /= total
+		} else {
+			candidates[i].Proportion = 1 / float64(len(candidates))
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Proportion != candidates[j].Proportion {
+			return candidates[i].Proportion > candidates[j].Proportion
+		}
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	d.Candidates = candidates
+
+	if len(candidates) > 0 {
+		d.PrimaryBreed = candidates[0].Breed
+		if d.Confidence == 0 {
+			d.Confidence = candidates[0].Confidence
+		}
+	}
+	d.MixedBreed = len(candidates) > 1 && candidates[1].Proportion >= mixedBreedThreshold
+
+	for i := range d.HealthPredispositions {
+		d.HealthPredispositions[i].Risk = strings.ToLower(strings.TrimSpace(d.HealthPredispositions[i].Risk))
+	}
+}
+
+// BreedLabel is the breed as recorded on a patient, e.g. "Labrador Retriever
+// mix" for mixed breeds.
+func (d *BreedDetection) BreedLabel() string {
+	if d.MixedBreed && !strings.Contains(strings.ToLower(d.PrimaryBreed), "mix") {
+		return d.PrimaryBreed + " mix"
+	}
+	return d.PrimaryBreed
+}
+
+// speciesWords maps the words a model uses for a species onto it.
+var speciesWords = map[string]string{
+	"dog": SpeciesDog, "dogs": SpeciesDog, "canine": SpeciesDog, "k9": SpeciesDog, "puppy": SpeciesDog,
+	"cat": SpeciesCat, "cats": SpeciesCat, "feline": SpeciesCat, "kitten": SpeciesCat,
+	"rabbit": SpeciesRabbit, "rabbits": SpeciesRabbit, "bunny": SpeciesRabbit,
+}
+
+// normalizeSpecies matches whole words, so "domestic cat" is a cat but
+// "bobcat" or "hotdog" is not.
+func normalizeSpecies(species string) string {
+	words := strings.FieldsFunc(strings.ToLower(species), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if s, ok := speciesWords[w]; ok {
+			return s
+		}
+	}
+	return SpeciesOther
+}
+
+// unitInterval maps a score given either as a fraction or a percentage onto
+// [0, 1].
+func unitInterval(v float64) float64 {
+	if v > 1 {
+		v /= 100
+	}
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// ImageBreedAssessment is the model's reading of a single photo.
+type ImageBreedAssessment struct {
+	ImageIndex int    `json:"image_index"`
+	View       string `json:"view"`
+	BreedDetection
+}
+
+type BreedAgreement struct {
+	ImageCount int `json:"image_count"`
+	// PrimaryBreedAgreement is the share of images whose top breed matches
+	// the consensus primary breed.
+	PrimaryBreedAgreement float64 `json:"primary_breed_agreement"`
+	SpeciesAgreement      float64 `json:"species_agreement"`
+	// BreedVotes counts how many images ranked each breed first.
+	BreedVotes map[string]int `json:"breed_votes"`
+	// ProportionSpread is the largest difference between images in the
+	// estimated proportion of a consensus breed.
+	ProportionSpread float64 `json:"proportion_spread"`
+}
+
+// BreedConsensus combines per-image assessments of the same animal.
+type BreedConsensus struct {
+	BreedDetection
+	Assessments []ImageBreedAssessment `json:"assessments"`
+	Agreement   BreedAgreement         `json:"agreement"`
+}
+
+// AggregateBreedAssessments builds a consensus from normalized per-image
+// assessments. Breed proportions are averaged with each image weighted by its
+// confidence, species is decided by majority vote and visible features are
+// merged.
+func AggregateBreedAssessments(assessments []ImageBreedAssessment, predispositions []HealthPredisposition) *BreedConsensus {
+	consensus := &BreedConsensus{
+		Assessments: assessments,
+		Agreement: BreedAgreement{
+			ImageCount: len(assessments),
+			BreedVotes: map[string]int{},
+		},
+	}
+	if len(assessments) == 0 {
+		return consensus
+	}
+
+	type breedStats struct {
+		name       string
+		weighted   float64
+		confidence float64
+		seen       int
+		min, max   float64
+	}
+	breeds := map[string]*breedStats{}
+	var order []string
+	speciesVotes := map[string]int{}
+	speciesConfidence := map[string]float64{}
+	featureSeen := map[string]bool{}
+	var features []string
+	totalWeight, totalConfidence := 0.0, 0.0
+
+	for _, a := range assessments {
+		weight := a.Confidence
+		if weight == 0 {
+			weight = 0.5
+		}
+		totalWeight += weight
+		totalConfidence += a.Confidence
+
+		speciesVotes[a.Species]++
+		speciesConfidence[a.Species] += a.SpeciesConfidence
+		for i, c := range a.Candidates {
+			key := strings.ToLower(c.Breed)
+			b, ok := breeds[key]
+			if !ok {
+				b = &breedStats{name: c.Breed, min: 1}
+				breeds[key] = b
+				order = append(order, key)
+			}
+			if i == 0 {
+				consensus.Agreement.BreedVotes[b.name]++
+			}
+			b.weighted += weight * c.Proportion
+			b.confidence += c.Confidence
+			b.seen++
+			if c.Proportion > b.max {
+				b.max = c.Proportion
+			}
+			if c.Proportion < b.min {
+				b.min = c.Proportion
+			}
+		}
+		for _, f := range a.VisibleFeatures {
+			if key := strings.ToLower(strings.TrimSpace(f)); key != "" && !featureSeen[key] {
+				featureSeen[key] = true
+				features = append(features, f)
+			}
+		}
+	}
+
+	n := float64(len(assessments))
+	for _, key := range order {
+		b := breeds[key]
+		// Images that did not mention the breed count as a proportion of 0.
+		if b.seen < len(assessments) {
+			b.min = 0
+		}
+		consensus.Candidates = append(consensus.Candidates, BreedCandidate{
+			Breed:      b.name,
+			Proportion: b.weighted / totalWeight,
+			Confidence: b.confidence / n,
+		})
+		if spread := b.max - b.min; spread > consensus.Agreement.ProportionSpread {
+			consensus.Agreement.ProportionSpread = spread
+		}
+	}
+
+	bestSpecies, bestVotes := SpeciesOther, 0
+	for species, votes := range speciesVotes {
+		if votes > bestVotes || (votes == bestVotes && speciesConfidence[species] > speciesConfidence[bestSpecies]) {
+			bestSpecies, bestVotes = species, votes
+		}
+	}
+	consensus.Species = bestSpecies
+	consensus.SpeciesConfidence = speciesConfidence[bestSpecies] / float64(bestVotes)
+	consensus.Agreement.SpeciesAgreement = float64(bestVotes) / n
+
+	consensus.Confidence = totalConfidence / n
+	consensus.VisibleFeatures = features
+	consensus.HealthPredispositions = predispositions
+	consensus.Normalize()
+
+	// Disagreement between photos lowers the overall confidence.
+	agreeing := 0
+	for _, a := range assessments {
+		if len(a.Candidates) > 0 && strings.EqualFold(a.Candidates[0].Breed, consensus.PrimaryBreed) {
+			agreeing++
+		}
+	}
+	consensus.Agreement.PrimaryBreedAgreement = float64(agreeing) / n
+	consensus.Confidence *= 0.5 + 0.5*consensus.Agreement.PrimaryBreedAgreement
+
+	return consensus
 }
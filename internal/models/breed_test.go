@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestNormalizeSpecies(t *testing.T) {
+	tests := []struct {
+		species string
+		want    string
+	}{
+		{"Dog", SpeciesDog},
+		{"domestic cat", SpeciesCat},
+		{"Feline (Felis catus)", SpeciesCat},
+		{"dwarf rabbit", SpeciesRabbit},
+		{"K9", SpeciesDog},
+		{"bobcat", SpeciesOther},
+		{"catfish", SpeciesOther},
+		{"prairie dogfish", SpeciesOther},
+		{"", SpeciesOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.species, func(t *testing.T) {
+			if got := normalizeSpecies(tt.species); got != tt.want {
+				t.Errorf("normalizeSpecies(%q) = %q, want %q", tt.species, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Clinical image types supported by image analysis.
+const (
+	ClinicalImageLesion      = "lesion"
+	ClinicalImageWound       = "wound"
+	ClinicalImageEarCytology = "ear_cytology"
+	ClinicalImageDental      = "dental"
+)
+
+var ClinicalImageTypes = []string{
+	ClinicalImageLesion,
+	ClinicalImageWound,
+	ClinicalImageEarCytology,
+	ClinicalImageDental,
+}
+
+// Disclaimer levels, from least to most cautious. They tell the reader how
+// much weight the analysis can bear before a veterinarian confirms it.
+const (
+	// DisclaimerInformational: a descriptive aid only.
+	DisclaimerInformational = "informational"
+	// DisclaimerVeterinaryReview: findings must be confirmed by examination
+	// or diagnostics before they inform treatment.
+	DisclaimerVeterinaryReview = "veterinary_review"
+	// DisclaimerUrgent: the image suggests a condition needing prompt
+	// veterinary attention.
+	DisclaimerUrgent = "urgent"
+)
+
+var disclaimerRank = map[string]int{
+	DisclaimerInformational:    0,
+	DisclaimerVeterinaryReview: 1,
+	DisclaimerUrgent:           2,
+}
+
+var disclaimerText = map[string]string{
+	DisclaimerInformational:    "AI-generated description for documentation support. Not a diagnosis.",
+	DisclaimerVeterinaryReview: "AI-generated findings. Confirm by physical examination and diagnostics before making treatment decisions.",
+	DisclaimerUrgent:           "AI-generated findings suggest a condition that may need prompt veterinary attention. Confirm by examination without delay.",
+}
+
+// ClinicalImageAnalysis is the structured reading of a clinical photo.
+type ClinicalImageAnalysis struct {
+	ImageType     string                `json:"image_type"`
+	BodySite      string                `json:"body_site"`
+	ImageQuality  string                `json:"image_quality"`
+	Observations  []ClinicalObservation `json:"observations"`
+	Differentials []ImageDifferential   `json:"differentials"`
+	// RecommendedDiagnostics lists tests that would confirm or rule out the
+	// differentials.
+	RecommendedDiagnostics []string  `json:"recommended_diagnostics"`
+	Confidence             float64   `json:"confidence"`
+	DisclaimerLevel        string    `json:"disclaimer_level"`
+	Disclaimer             string    `json:"disclaimer"`
+	Model                  string    `json:"model"`
+	AnalyzedAt             time.Time `json:"analyzed_at"`
+}
+
+type ClinicalObservation struct {
+	Location     string `json:"location"`
+	SizeEstimate string `json:"size_estimate"`
+	Color        string `json:"color"`
+	Morphology   string `json:"morphology"`
+	Description  string `json:"description"`
+}
+
+type ImageDifferential struct {
+	Diagnosis  string `json:"diagnosis"`
+	Likelihood string `json:"likelihood"`
+	Rationale  string `json:"rationale"`
+}
+
+// IsClinicalImageType reports whether t is a supported clinical image type.
+func IsClinicalImageType(t string) bool {
+	for _, known := range ClinicalImageTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize calibrates a raw model answer. The model may raise the disclaimer
+// level but not drop it below what the reading warrants: ear cytology needs
+// microscopy to confirm, and readings that suggest differentials or have low
+// confidence always need veterinary review.
+func (a *ClinicalImageAnalysis) Normalize() {
+	a.Confidence = unitInterval(a.Confidence)
+
+	level := strings.ToLower(strings.TrimSpace(a.DisclaimerLevel))
+	if _, ok := disclaimerRank[level]; !ok {
+		level = DisclaimerVeterinaryReview
+	}
+	if a.ImageType == ClinicalImageEarCytology || a.Confidence < 0.5 || len(a.Differentials) > 0 {
+		level = maxDisclaimer(level, DisclaimerVeterinaryReview)
+	}
+	a.DisclaimerLevel = level
+	a.Disclaimer = disclaimerText[level]
+
+	observations := a.Observations[:0]
+	for _, o := range a.Observations {
+		if o.Location != "" || o.Morphology != "" || o.Description != "" {
+			observations = append(observations, o)
+		}
+	}
+	a.Observations = observations
+}
+
+func maxDisclaimer(a, b string) string {
+	if disclaimerRank[b] > disclaimerRank[a] {
+		return b
+	}
+	return a
+}
+
+// Findings renders the observations as examination finding lines for a SOAP
+// note.
+func (a *ClinicalImageAnalysis) Findings() []string {
+	findings := make([]string, 0, len(a.Observations))
+	for _, o := range a.Observations {
+		var parts []string
+		for _, p := range []string{o.Morphology, o.Color, o.SizeEstimate} {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		finding := strings.Join(parts, ", ")
+		if finding == "" {
+			finding = o.Description
+		}
+		if o.Location != "" {
+			finding = fmt.Sprintf("%s: %s", o.Location, finding)
+		}
+		findings = append(findings, fmt.Sprintf("[%s photo] %s", strings.ReplaceAll(a.ImageType, "_", " "), finding))
+	}
+	return findings
+}
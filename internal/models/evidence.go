@@ -0,0 +1,365 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"vet-tails/ai/internal/formulary"
+)
+
+// Evidence statuses of a SOAP item.
+const (
+	EvidenceSupported   = "supported"
+	EvidenceUnsupported = "unsupported"
+)
+
+// minEvidenceCoverage is the share of an item's key terms the transcript
+// must contain for the item to count as supported.
+const minEvidenceCoverage = 0.5
+
+// maxEvidenceSpans caps the spans linked to one item.
+const maxEvidenceSpans = 3
+
+// EvidenceSpan is a sentence of the transcript. Start and End are character
+// offsets, End exclusive.
+type EvidenceSpan struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// ItemEvidence links one SOAP item to the transcript spans supporting it.
+type ItemEvidence struct {
+	// Field is the item's JSON path, e.g. "subjective.symptoms[1]".
+	Field  string `json:"field"`
+	Item   string `json:"item"`
+	Status string `json:"status"`
+	// Coverage is the share of the item's key terms found in the spans.
+	Coverage float64        `json:"coverage"`
+	Spans    []EvidenceSpan `json:"spans"`
+}
+
+// LinkEvidence links each item the note extracted from its transcript to the
+// transcript sentences that mention it. Items whose key terms mostly do not
+// appear are marked unsupported. Image findings are left out since their
+// evidence is the image.
+func (n *Note) LinkEvidence() {
+	n.Evidence = nil
+	if strings.TrimSpace(n.Transcript) == "" {
+		return
+	}
+	sentences := splitSentences(n.Transcript)
+
+	text := func(field, item string) {
+		if item = strings.TrimSpace(item); item != "" {
+			n.addEvidence(field, item, evidenceTerms(item), sentences)
+		}
+	}
+	list := func(field string, items []string, skip map[string]bool) {
+		for i, item := range items {
+			if !skip[item] {
+				text(fmt.Sprintf("%s[%d]", field, i), item)
+			}
+		}
+	}
+	measurement := func(field string, m Measurement) {
+		if m.Original == "" {
+			return
+		}
+		// Only the number needs to be stated; units and labels vary.
+		terms := numberTerms(m.Original)
+		if len(terms) == 0 {
+			terms = evidenceTerms(m.Original)
+		}
+		n.addEvidence(field, m.Original, terms, sentences)
+	}
+
+	text("subjective.chief_complaint", n.Subjective.ChiefComplaint)
+	text("subjective.duration", n.Subjective.Duration)
+	text("subjective.history", n.Subjective.History)
+	list("subjective.symptoms", n.Subjective.Symptoms, nil)
+
+	v := &n.Objective.VitalSigns
+	measurement("objective.vital_signs.temperature", v.Temperature)
+	measurement("objective.vital_signs.heart_rate", v.HeartRate)
+	measurement("objective.vital_signs.respiratory_rate", v.RespiratoryRate)
+	measurement("objective.vital_signs.weight", v.Weight)
+	text("objective.vital_signs.mucous_membranes", v.MucousMembranes)
+	measurement("objective.vital_signs.capillary_refill_time", v.CapillaryRefillTime)
+	text("objective.vital_signs.hydration", v.Hydration)
+	measurement("objective.vital_signs.pain_score", v.PainScore)
+	measurement("objective.vital_signs.body_condition_score", v.BodyConditionScore)
+	text("objective.vital_signs.general_condition", v.GeneralCondition)
+
+	imageFindings := map[string]bool{}
+	for i := range n.Objective.ImageFindings {
+		for _, f := range n.Objective.ImageFindings[i].Findings() {
+			imageFindings[f] = true
+		}
+	}
+	list("objective.examination_findings", n.Objective.ExaminationFindings, imageFindings)
+
+	text("assessment.primary_diagnosis", n.Assessment.PrimaryDiagnosis)
+	list("assessment.differentials", n.Assessment.Differentials, nil)
+
+	list("plan.immediate_treatment", n.Plan.ImmediateTreatment, nil)
+	for i, order := range n.Plan.Medications {
+		n.addMedicationEvidence(fmt.Sprintf("plan.medications[%d]", i), order, sentences)
+	}
+	text("plan.follow_up", n.Plan.FollowUp)
+	list("plan.client_education", n.Plan.ClientEducation, nil)
+}
+
+// UnsupportedItems returns the items with no evidence in the transcript.
+func (n *Note) UnsupportedItems() []ItemEvidence {
+	var items []ItemEvidence
+	for _, e := range n.Evidence {
+		if e.Status == EvidenceUnsupported {
+			items = append(items, e)
+		}
+	}
+	return items
+}
+
+func (n *Note) addEvidence(field, item string, terms []string, sentences []sentence) {
+	if len(terms) == 0 {
+		return
+	}
+	// Greedily pick the sentences that add the most uncovered terms.
+	covered := map[string]bool{}
+	var spans []EvidenceSpan
+	for len(spans) < maxEvidenceSpans {
+		best, gain := -1, 0
+		for i, s := range sentences {
+			g := 0
+			for _, t := range terms {
+				if !covered[t] && s.terms[t] {
+					g++
+				}
+			}
+			if g > gain {
+				best, gain = i, g
+			}
+		}
+		if best < 0 {
+			break
+		}
+		for _, t := range terms {
+			if sentences[best].terms[t] {
+				covered[t] = true
+			}
+		}
+		spans = append(spans, sentences[best].span)
+	}
+	n.appendEvidence(field, item, float64(len(covered))/float64(len(terms)), spans)
+}
+
+// addMedicationEvidence links an order to the sentences naming its drug,
+// under any of its names.
+func (n *Note) addMedicationEvidence(field string, order MedicationOrder, sentences []sentence) {
+	item := order.String()
+	drug, ok := formulary.Lookup(order.Drug)
+	if !ok {
+		n.addEvidence(field, item, evidenceTerms(order.Drug), sentences)
+		return
+	}
+	var spans []EvidenceSpan
+	for _, s := range sentences {
+		for _, mention := range formulary.FindDrugs(s.span.Text) {
+			if mention.Drug == drug && len(spans) < maxEvidenceSpans {
+				spans = append(spans, s.span)
+				break
+			}
+		}
+	}
+	coverage := 0.0
+	if len(spans) > 0 {
+		coverage = 1
+	}
+	n.appendEvidence(field, item, coverage, spans)
+}
+
+func (n *Note) appendEvidence(field, item string, coverage float64, spans []EvidenceSpan) {
+	status := EvidenceSupported
+	if coverage < minEvidenceCoverage {
+		status = EvidenceUnsupported
+	}
+	if spans == nil {
+		spans = []EvidenceSpan{}
+	}
+	n.Evidence = append(n.Evidence, ItemEvidence{
+		Field:    field,
+		Item:     item,
+		Status:   status,
+		Coverage: math.Round(coverage*100) / 100,
+		Spans:    spans,
+	})
+}
+
+type sentence struct {
+	span  EvidenceSpan
+	terms map[string]bool
+}
+
+// splitSentences splits the transcript at sentence ends and line breaks.
+func splitSentences(transcript string) []sentence {
+	var sentences []sentence
+	runes := []rune(transcript)
+	start := 0
+	flush := func(end int) {
+		s, e := start, end
+		for s < e && unicode.IsSpace(runes[s]) {
+			s++
+		}
+		for e > s && unicode.IsSpace(runes[e-1]) {
+			e--
+		}
+		start = end
+		if s == e {
+			return
+		}
+		text := string(runes[s:e])
+		terms := map[string]bool{}
+		for _, t := range evidenceTerms(text) {
+			terms[t] = true
+		}
+		sentences = append(sentences, sentence{span: EvidenceSpan{Start: s, End: e, Text: text}, terms: terms})
+	}
+	for i, r := range runes {
+		switch r {
+		case '\n':
+			flush(i + 1)
+		case '.', '!', '?':
+			// Decimal points are not sentence ends.
+			if r == '.' && i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) {
+				continue
+			}
+			flush(i + 1)
+		}
+	}
+	flush(len(runes))
+	return sentences
+}
+
+// evidencePhrases map lay and clinical wordings of common presenting signs
+// onto one term, so either supports the other.
+var evidencePhrases = []struct {
+	pattern *regexp.Regexp
+	term    string
+}{
+	{regexp.MustCompile(`\b(throw(s|ing)? up|threw up|being sick|emesis|vomit\w*)\b`), "vomit"},
+	{regexp.MustCompile(`\b(loose (stools?|poo\w*)|runny (stools?|poo\w*)|diarrhoea\w*|diarrhea\w*)\b`), "diarrhea"},
+	{regexp.MustCompile(`\b(not eating|off (his|her|its|their) food|off food|won'?t eat|hasn'?t (been )?eat\w*|(decreased|reduced|poor) appetite|inappeten\w*|anorexi\w*|hyporexi\w*)\b`), "inappetence"},
+	{regexp.MustCompile(`\b(letharg\w*|low energy|(more )?tired|sleeping more)\b`), "lethargy"},
+	{regexp.MustCompile(`\b(itch\w*|scratch\w*|prurit\w*)\b`), "pruritus"},
+	{regexp.MustCompile(`\b(limp\w*|lame\w*)\b`), "lameness"},
+	{regexp.MustCompile(`\b(drinking (a lot )?more|increased thirst|polydips\w*)\b`), "polydipsia"},
+	{regexp.MustCompile(`\b((peeing|urinating|weeing) (a lot )?more|increased urination|polyuri\w*)\b`), "polyuria"},
+	{regexp.MustCompile(`\b(temp|temperature)\b`), "temperature"},
+}
+
+var evidenceStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true, "in": true,
+	"on": true, "for": true, "with": true, "at": true, "by": true, "from": true, "is": true, "are": true,
+	"was": true, "were": true, "be": true, "been": true, "has": true, "have": true, "had": true,
+	"it": true, "its": true, "this": true, "that": true, "as": true,
+	"he": true, "she": true, "his": true, "her": true, "they": true, "their": true, "him": true,
+	"patient": true, "pet": true, "owner": true, "reports": true, "reported": true, "noted": true,
+	"some": true, "any": true, "very": true, "also": true, "if": true, "so": true,
+	"per": true, "about": true, "since": true, "after": true,
+}
+
+// negationCues negate the words that follow them, so "no vomiting" does
+// not support "vomiting".
+var negationCues = map[string]bool{
+	"no": true, "not": true, "without": true, "never": true, "none": true, "nil": true,
+	"deny": true, "denies": true, "denied": true, "negative": true, "neither": true, "nor": true,
+}
+
+// negationBreaks end a negation early, as in "no vomiting but diarrhea".
+var negationBreaks = map[string]bool{
+	"but": true, "however": true, "although": true, "though": true, "except": true, "yet": true,
+}
+
+// negationWindow is how many words after a cue are negated.
+const negationWindow = 4
+
+// negatedPrefix marks a negated term. Negated terms only support items that
+// are negated too.
+const negatedPrefix = "not:"
+
+var contractionPattern = regexp.MustCompile(`\b\w+n't\b`)
+
+var numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+// numberTerms returns the numbers in text.
+func numberTerms(text string) []string {
+	return numberPattern.FindAllString(text, -1)
+}
+
+// evidenceTerms reduces text to its key terms: lower-cased, stemmed words
+// and numbers without stopwords, with common signs mapped to one term. Words
+// shortly after a negation cue are marked with negatedPrefix.
+func evidenceTerms(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "’", "'")
+	for _, p := range evidencePhrases {
+		text = p.pattern.ReplaceAllString(text, " "+p.term+" ")
+	}
+	text = contractionPattern.ReplaceAllString(text, " not ")
+	seen := map[string]bool{}
+	var terms []string
+	negated := 0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.'
+	}) {
+		word = strings.Trim(word, ".")
+		if word == "" {
+			continue
+		}
+		if negationCues[word] {
+			negated = negationWindow
+			continue
+		}
+		if negationBreaks[word] {
+			negated = 0
+			continue
+		}
+		inNegation := negated > 0
+		if negated > 0 {
+			negated--
+		}
+		if evidenceStopwords[word] {
+			continue
+		}
+		if !unicode.IsDigit([]rune(word)[0]) {
+			if len([]rune(word)) < 2 {
+				continue
+			}
+			word = stem(word)
+		}
+		if inNegation {
+			word = negatedPrefix + word
+		}
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// stem strips common English suffixes so "vomiting", "vomited" and
+// "vomits" compare equal.
+func stem(word string) string {
+	if strings.HasSuffix(word, "ss") {
+		return word
+	}
+	for _, suffix := range []string{"ing", "ed", "es", "s", "ly"} {
+		if base, ok := strings.CutSuffix(word, suffix); ok && len(base) >= 3 {
+			return base
+		}
+	}
+	return word
+}
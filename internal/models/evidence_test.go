@@ -0,0 +1,87 @@
+package models
+
+import "testing"
+
+func findEvidence(t *testing.T, n *Note, field string) ItemEvidence {
+	t.Helper()
+	for _, e := range n.Evidence {
+		if e.Field == field {
+			return e
+		}
+	}
+	t.Fatalf("no evidence for %s in %+v", field, n.Evidence)
+	return ItemEvidence{}
+}
+
+func TestLinkEvidenceSymptoms(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+		symptom    string
+		want       string
+	}{
+		{"stated", "He has been vomiting since Monday.", "vomiting", EvidenceSupported},
+		{"lay wording", "He keeps throwing up after meals.", "vomiting", EvidenceSupported},
+		{"absent", "Eating well, bright and alert.", "vomiting", EvidenceUnsupported},
+		{"negated", "Owner says no vomiting and no diarrhea.", "vomiting", EvidenceUnsupported},
+		{"negated in list", "Owner says no vomiting and no diarrhea.", "diarrhea", EvidenceUnsupported},
+		{"negated with or", "No vomiting or diarrhea.", "diarrhea", EvidenceUnsupported},
+		{"denied", "Owner denies any coughing.", "coughing", EvidenceUnsupported},
+		{"contraction", "He isn't coughing at all.", "coughing", EvidenceUnsupported},
+		{"negation ends at but", "No vomiting but diarrhea since yesterday.", "diarrhea", EvidenceSupported},
+		{"negation before but", "No vomiting but diarrhea since yesterday.", "vomiting", EvidenceUnsupported},
+		{"negated item", "Owner says no diarrhea.", "no diarrhea", EvidenceSupported},
+		{"not eating is a sign", "She is not eating her dinner.", "inappetence", EvidenceSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := Note{Transcript: tt.transcript}
+			n.Subjective.Symptoms = []string{tt.symptom}
+			n.LinkEvidence()
+			got := findEvidence(t, &n, "subjective.symptoms[0]")
+			if got.Status != tt.want {
+				t.Errorf("status = %s (coverage %.2f, spans %+v), want %s", got.Status, got.Coverage, got.Spans, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkEvidenceSpans(t *testing.T) {
+	n := Note{Transcript: "Temp is 102.9 today. We'll give Cerenia and recheck in 3 days."}
+	n.Objective.VitalSigns.Temperature = ParseMeasurement("102.9 F")
+	n.Plan.Medications = []MedicationOrder{{Drug: "maropitant"}, {Drug: "amoxicillin"}}
+	n.Plan.FollowUp = "recheck in 3 days"
+	n.LinkEvidence()
+
+	tests := []struct {
+		field     string
+		want      string
+		spanStart int
+		spanEnd   int
+	}{
+		{"objective.vital_signs.temperature", EvidenceSupported, 0, 20},
+		{"plan.medications[0]", EvidenceSupported, 21, 62},
+		{"plan.medications[1]", EvidenceUnsupported, -1, -1},
+		{"plan.follow_up", EvidenceSupported, 21, 62},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got := findEvidence(t, &n, tt.field)
+			if got.Status != tt.want {
+				t.Fatalf("status = %s, want %s", got.Status, tt.want)
+			}
+			if tt.spanStart < 0 {
+				if len(got.Spans) != 0 {
+					t.Errorf("spans = %+v, want none", got.Spans)
+				}
+				return
+			}
+			if len(got.Spans) == 0 || got.Spans[0].Start != tt.spanStart || got.Spans[0].End != tt.spanEnd {
+				t.Fatalf("spans = %+v, want [%d, %d)", got.Spans, tt.spanStart, tt.spanEnd)
+			}
+			if text := string([]rune(n.Transcript)[tt.spanStart:tt.spanEnd]); text != got.Spans[0].Text {
+				t.Errorf("span text = %q, transcript has %q", got.Spans[0].Text, text)
+			}
+		})
+	}
+}
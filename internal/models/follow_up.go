@@ -0,0 +1,112 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Follow-up task severities, from least to most pressing.
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+	SeverityUrgent = "urgent"
+)
+
+var severityRank = map[string]int{
+	SeverityLow:    1,
+	SeverityMedium: 2,
+	SeverityHigh:   3,
+	SeverityUrgent: 4,
+}
+
+// severityResponseTime is how soon the clinic should act on a task.
+var severityResponseTime = map[string]time.Duration{
+	SeverityLow:    7 * 24 * time.Hour,
+	SeverityMedium: 72 * time.Hour,
+	SeverityHigh:   24 * time.Hour,
+	SeverityUrgent: 4 * time.Hour,
+}
+
+// Follow-up task statuses.
+const (
+	TaskStatusOpen         = "open"
+	TaskStatusAcknowledged = "acknowledged"
+	TaskStatusResolved     = "resolved"
+)
+
+// Audit actions recorded for follow-up tasks.
+const (
+	TaskActionCreated      = "created"
+	TaskActionAcknowledged = "acknowledged"
+	TaskActionResolved     = "resolved"
+)
+
+// FollowUpTask asks a clinic to follow up on concerns raised in a patient's
+// activity log.
+type FollowUpTask struct {
+	ID            uint     `json:"id" gorm:"primaryKey"`
+	PatientID     uint     `json:"patient_id" gorm:"index"`
+	ClinicID      string   `json:"clinic_id" gorm:"index"`
+	ActivityLogID uint     `json:"activity_log_id" gorm:"index"`
+	Title         string   `json:"title"`
+	Concerns      []string `json:"concerns" gorm:"serializer:json"`
+	Severity      string   `json:"severity"`
+	// SeverityRank orders the queue, most pressing first.
+	SeverityRank      int        `json:"-" gorm:"index"`
+	TriageReasoning   string     `json:"triage_reasoning"`
+	RecommendedAction string     `json:"recommended_action"`
+	Status            string     `json:"status" gorm:"index"`
+	DueAt             time.Time  `json:"due_at"`
+	AcknowledgedBy    string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt    *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedBy        string     `json:"resolved_by,omitempty"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty"`
+	Resolution        string     `json:"resolution,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	Events []FollowUpTaskEvent `json:"events,omitempty" gorm:"foreignKey:TaskID"`
+}
+
+// FollowUpTaskEvent is one entry in a task's audit trail.
+type FollowUpTaskEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	TaskID     uint      `json:"task_id" gorm:"index"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ConcernTriage is the model's assessment of how pressing a concern is.
+type ConcernTriage struct {
+	Severity          string `json:"severity"`
+	Reasoning         string `json:"reasoning"`
+	RecommendedAction string `json:"recommended_action"`
+}
+
+// NormalizeSeverity maps a severity onto the known values. Unknown values
+// become medium so the task is still seen.
+func NormalizeSeverity(severity string) string {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	switch severity {
+	case "critical", "emergency":
+		return SeverityUrgent
+	case "moderate":
+		return SeverityMedium
+	}
+	if _, ok := severityRank[severity]; ok {
+		return severity
+	}
+	return SeverityMedium
+}
+
+// SetSeverity sets the severity with its queue rank and due time.
+func (t *FollowUpTask) SetSeverity(severity string, from time.Time) {
+	t.Severity = NormalizeSeverity(severity)
+	t.SeverityRank = severityRank[t.Severity]
+	t.DueAt = from.Add(severityResponseTime[t.Severity])
+}
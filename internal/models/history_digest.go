@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// VisitSummary is a short summary of one note, generated once and reused when
+// a patient's history is too long to summarize in a single prompt.
+type VisitSummary struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PatientID uint      `json:"patient_id" gorm:"index"`
+	NoteID    uint      `json:"note_id" gorm:"uniqueIndex"`
+	VisitDate time.Time `json:"visit_date"`
+	Summary   string    `json:"summary"`
+	// Fingerprint identifies the note content the summary was generated from.
+	Fingerprint string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// HistoryDigest condenses a patient's visit summaries for one calendar year.
+type HistoryDigest struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	PatientID  uint   `json:"patient_id" gorm:"uniqueIndex:idx_history_digest_year"`
+	Year       int    `json:"year" gorm:"uniqueIndex:idx_history_digest_year"`
+	VisitCount int    `json:"visit_count"`
+	Summary    string `json:"summary"`
+	// Fingerprint identifies the visit summaries the digest was built from.
+	Fingerprint string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Measurement is a numeric clinical measurement. After normalization Value
+// and Unit are in the canonical unit for the quantity and Original keeps what
+// was written. Value is nil when no number was given or the value was
+// rejected.
+type Measurement struct {
+	Value *float64 `json:"value"`
+	Unit  string   `json:"unit"`
+	// Original is the measurement as written, e.g. "101.5 °F".
+	Original string `json:"original,omitempty"`
+	// Rejected explains why a value was discarded as physiologically
+	// impossible or in an unknown unit.
+	Rejected    string `json:"rejected,omitempty"`
+	Status      string `json:"status,omitempty"`
+	NormalRange string `json:"normal_range,omitempty"`
+}
+
+var measurementPattern = regexp.MustCompile(`^[<>~≈]?\s*(-?\d+(?:\.\d+)?)\s*(.*)$`)
+
+// UnmarshalJSON accepts a {"value", "unit"} object, a bare number, or text
+// such as "38.6 °C" or "3/9", which older notes store.
+func (m *Measurement) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*m = ParseMeasurement(text)
+		return nil
+	}
+	var n float64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*m = Measurement{Value: &n}
+		return nil
+	}
+	type measurement Measurement
+	var raw struct {
+		measurement
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = Measurement(raw.measurement)
+	if len(raw.Value) == 0 || string(raw.Value) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw.Value, &n); err == nil {
+		m.Value = &n
+		return nil
+	}
+	if err := json.Unmarshal(raw.Value, &text); err != nil {
+		return fmt.Errorf("invalid measurement value: %s", raw.Value)
+	}
+	parsed := ParseMeasurement(text)
+	m.Value = parsed.Value
+	if m.Unit == "" {
+		m.Unit = parsed.Unit
+	}
+	if m.Original == "" && m.Value == nil {
+		m.Original = parsed.Original
+	}
+	return nil
+}
+
+// ParseMeasurement reads text such as "120 bpm", "<2 sec" or "4/9". Text
+// without a leading number, such as "not recorded", gives no value.
+func ParseMeasurement(text string) Measurement {
+	text = strings.TrimSpace(text)
+	m := Measurement{Original: text}
+	match := measurementPattern.FindStringSubmatch(text)
+	if match == nil {
+		return m
+	}
+	v, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return m
+	}
+	m.Value = &v
+	m.Unit = strings.TrimSpace(match[2])
+	return m
+}
+
+// String renders the measurement as "38.6 °C" or "4/9", or its original text
+// when it has no value.
+func (m Measurement) String() string {
+	if m.Value == nil {
+		return m.Original
+	}
+	return formatQuantity(*m.Value, m.Unit)
+}
+
+func formatQuantity(value float64, unit string) string {
+	if strings.HasPrefix(unit, "/") {
+		return fmt.Sprintf("%g%s", value, unit)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%g %s", value, unit))
+}
+
+// Canonical units.
+const (
+	UnitCelsius     = "°C"
+	UnitKilogram    = "kg"
+	UnitBeatsPerMin = "beats/min"
+	UnitBreathsPerM = "breaths/min"
+	UnitSecond      = "s"
+	UnitPainScale   = "/10"
+	UnitBCSScale    = "/9"
+)
+
+// quantity describes how one kind of measurement is normalized.
+type quantity struct {
+	name string
+	unit string
+	// units maps accepted spellings, lower-cased, to a conversion into the
+	// canonical unit.
+	units map[string]func(float64) float64
+	// bare converts a value written without a unit.
+	bare func(float64) float64
+	// min and max bound physiologically possible values in the canonical
+	// unit.
+	min, max float64
+}
+
+func same(v float64) float64 { return v }
+
+var (
+	temperatureQuantity = quantity{
+		name: "temperature", unit: UnitCelsius,
+		units: map[string]func(float64) float64{
+			"°c": same, "c": same, "celsius": same, "degrees c": same, "degrees celsius": same, "deg c": same,
+			"°f": fahrenheit, "f": fahrenheit, "fahrenheit": fahrenheit, "degrees f": fahrenheit, "degrees fahrenheit": fahrenheit, "deg f": fahrenheit,
+		},
+		// Without a unit, values above 50 can only be Fahrenheit.
+		bare: func(v float64) float64 {
+			if v > 50 {
+				return fahrenheit(v)
+			}
+			return v
+		},
+		min: 25, max: 45,
+	}
+	heartRateQuantity = quantity{
+		name: "heart rate", unit: UnitBeatsPerMin,
+		units: map[string]func(float64) float64{
+			"bpm": same, "beats/min": same, "beats per minute": same, "beats/minute": same, "/min": same, "per minute": same, "min-1": same,
+		},
+		bare: same, min: 10, max: 450,
+	}
+	respiratoryRateQuantity = quantity{
+		name: "respiratory rate", unit: UnitBreathsPerM,
+		units: map[string]func(float64) float64{
+			"breaths/min": same, "breaths per minute": same, "breaths/minute": same, "brpm": same, "rpm": same, "bpm": same, "/min": same, "per minute": same, "min-1": same,
+		},
+		bare: same, min: 2, max: 250,
+	}
+	weightQuantity = quantity{
+		name: "weight", unit: UnitKilogram,
+		units: map[string]func(float64) float64{
+			"kg": same, "kgs": same, "kilogram": same, "kilograms": same, "kilo": same, "kilos": same,
+			"g": grams, "gram": grams, "grams": grams,
+			"lb": pounds, "lbs": pounds, "pound": pounds, "pounds": pounds, "#": pounds,
+			"oz": ounces, "ounce": ounces, "ounces": ounces,
+		},
+		bare: same, min: 0.01, max: 1500,
+	}
+	crtQuantity = quantity{
+		name: "capillary refill time", unit: UnitSecond,
+		units: map[string]func(float64) float64{
+			"s": same, "sec": same, "secs": same, "second": same, "seconds": same,
+			"ms": func(v float64) float64 { return v / 1000 },
+		},
+		bare: same, min: 0, max: 10,
+	}
+	// Pain scores on other scales, such as the 0–4 Colorado scale, are
+	// rescaled to 0–10.
+	painQuantity = quantity{
+		name: "pain score", unit: UnitPainScale,
+		units: map[string]func(float64) float64{
+			"/10": same, "/4": func(v float64) float64 { return v * 10 / 4 }, "/5": func(v float64) float64 { return v * 2 },
+		},
+		bare: same, min: 0, max: 10,
+	}
+	// 5-point body condition scores map onto the 9-point scale.
+	bcsQuantity = quantity{
+		name: "body condition score", unit: UnitBCSScale,
+		units: map[string]func(float64) float64{
+			"/9": same, "/5": func(v float64) float64 { return v*2 - 1 },
+		},
+		bare: same, min: 1, max: 9,
+	}
+)
+
+func fahrenheit(v float64) float64 { return (v - 32) * 5 / 9 }
+func pounds(v float64) float64     { return v * 0.45359237 }
+func ounces(v float64) float64     { return v * 0.028349523125 }
+func grams(v float64) float64      { return v / 1000 }
+
+// normalize converts the measurement to the quantity's canonical unit,
+// keeping the original, and rejects values outside the possible range.
+// Normalizing a canonical measurement again leaves it unchanged.
+func (m *Measurement) normalize(q quantity) {
+	if m.Value == nil {
+		return
+	}
+	m.Rejected = ""
+	if m.Original == "" {
+		m.Original = formatQuantity(*m.Value, m.Unit)
+	}
+
+	unit := strings.ToLower(strings.Join(strings.Fields(m.Unit), " "))
+	unit = strings.TrimSuffix(unit, ".")
+	convert, ok := q.units[unit]
+	switch {
+	case unit == "":
+		convert = q.bare
+	case unit == strings.ToLower(q.unit):
+		convert = same
+	case !ok:
+		m.Rejected = fmt.Sprintf("unknown %s unit %q", q.name, m.Unit)
+		m.Value = nil
+		return
+	}
+
+	v := math.Round(convert(*m.Value)*100) / 100
+	if v < q.min || v > q.max {
+		m.Rejected = fmt.Sprintf("%s of %s is not physiologically possible", q.name, m.Original)
+		m.Value = nil
+		return
+	}
+	m.Value = &v
+	m.Unit = q.unit
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMeasurement(t *testing.T) {
+	tests := []struct {
+		text     string
+		hasValue bool
+		value    float64
+		unit     string
+	}{
+		{"101.5 °F", true, 101.5, "°F"},
+		{"120 bpm", true, 120, "bpm"},
+		{"<2 sec", true, 2, "sec"},
+		{"4/9", true, 4, "/9"},
+		{"38", true, 38, ""},
+		{"not recorded", false, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m := ParseMeasurement(tt.text)
+			if m.Original != tt.text {
+				t.Errorf("original = %q, want %q", m.Original, tt.text)
+			}
+			if (m.Value != nil) != tt.hasValue {
+				t.Fatalf("value = %v, want present %v", m.Value, tt.hasValue)
+			}
+			if tt.hasValue && (*m.Value != tt.value || m.Unit != tt.unit) {
+				t.Errorf("parsed %v %q, want %v %q", *m.Value, m.Unit, tt.value, tt.unit)
+			}
+		})
+	}
+}
+
+func TestVitalSignsNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		field    func(v *VitalSigns) *Measurement
+		want     float64
+		unit     string
+		rejected bool
+	}{
+		{"fahrenheit", "102.2 F", temperature, 39, UnitCelsius, false},
+		{"celsius", "38.6 °C", temperature, 38.6, UnitCelsius, false},
+		{"bare fahrenheit", "101.3", temperature, 38.5, UnitCelsius, false},
+		{"impossible temperature", "150 F", temperature, 0, "", true},
+		{"unknown unit", "38 kelvin", temperature, 0, "", true},
+		{"pounds", "22 lbs", weight, 9.98, UnitKilogram, false},
+		{"grams", "850 g", weight, 0.85, UnitKilogram, false},
+		{"heart rate", "120 bpm", heartRate, 120, UnitBeatsPerMin, false},
+		{"five point body condition", "3/5", bcs, 5, UnitBCSScale, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v VitalSigns
+			*tt.field(&v) = ParseMeasurement(tt.text)
+			v.Normalize()
+			m := tt.field(&v)
+			if m.Original != tt.text {
+				t.Errorf("original = %q, want %q", m.Original, tt.text)
+			}
+			if tt.rejected {
+				if m.Value != nil || m.Rejected == "" {
+					t.Errorf("measurement = %+v, want rejected", m)
+				}
+				return
+			}
+			if m.Value == nil || *m.Value != tt.want || m.Unit != tt.unit {
+				t.Fatalf("measurement = %+v, want %v %s", m, tt.want, tt.unit)
+			}
+
+			// Normalizing again leaves a canonical value unchanged.
+			v.Normalize()
+			if *m.Value != tt.want || m.Unit != tt.unit {
+				t.Errorf("renormalized to %v %s", *m.Value, m.Unit)
+			}
+		})
+	}
+}
+
+func TestVitalSignsWeightKg(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{"22 lbs", 9.98, true},
+		{"4.2 kg", 4.2, true},
+		{"4.2", 4.2, true},
+		{"not weighed", 0, false},
+		{"9000 kg", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			v := VitalSigns{Weight: ParseMeasurement(tt.text)}
+			got, ok := v.WeightKg()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("WeightKg() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+			if v.Weight.Original != tt.text {
+				t.Errorf("WeightKg changed the stored weight to %+v", v.Weight)
+			}
+		})
+	}
+}
+
+func TestVitalSignsEvaluate(t *testing.T) {
+	var v VitalSigns
+	v.Temperature = ParseMeasurement("40.1 C")
+	v.HeartRate = ParseMeasurement("180 bpm")
+	v.Normalize()
+	v.Evaluate("feline")
+
+	if v.Temperature.Status != VitalHigh {
+		t.Errorf("temperature status = %s, want high", v.Temperature.Status)
+	}
+	if v.HeartRate.Status != VitalNormal {
+		t.Errorf("heart rate status = %s, want normal for a cat", v.HeartRate.Status)
+	}
+	if len(v.Abnormal) != 1 {
+		t.Errorf("abnormal = %v, want only the temperature", v.Abnormal)
+	}
+}
+
+func TestMeasurementUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data     string
+		hasValue bool
+		value    float64
+		unit     string
+	}{
+		{`"38.6 °C"`, true, 38.6, "°C"},
+		{`39.1`, true, 39.1, ""},
+		{`{"value": 38.5, "unit": "°C"}`, true, 38.5, "°C"},
+		{`{"value": "101 F"}`, true, 101, "F"},
+		{`{"value": null, "original": "not taken"}`, false, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			var m Measurement
+			if err := json.Unmarshal([]byte(tt.data), &m); err != nil {
+				t.Fatal(err)
+			}
+			if (m.Value != nil) != tt.hasValue {
+				t.Fatalf("value = %v, want present %v", m.Value, tt.hasValue)
+			}
+			if tt.hasValue && (*m.Value != tt.value || m.Unit != tt.unit) {
+				t.Errorf("decoded %v %q, want %v %q", *m.Value, m.Unit, tt.value, tt.unit)
+			}
+		})
+	}
+}
+
+func temperature(v *VitalSigns) *Measurement { return &v.Temperature }
+func weight(v *VitalSigns) *Measurement      { return &v.Weight }
+func heartRate(v *VitalSigns) *Measurement   { return &v.HeartRate }
+func bcs(v *VitalSigns) *Measurement         { return &v.BodyConditionScore }
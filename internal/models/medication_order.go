@@ -0,0 +1,189 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"vet-tails/ai/internal/formulary"
+)
+
+// MedicationOrder is a structured medication entry in a SOAP plan.
+type MedicationOrder struct {
+	// Drug is the generic name from the drug dictionary when recognized,
+	// otherwise the name as written.
+	Drug string `json:"drug"`
+	// InDictionary is set when Drug was matched in the local dictionary.
+	InDictionary bool `json:"in_dictionary"`
+	// Strength is the product strength, e.g. "250 mg" or "1.5 mg/ml".
+	Strength string  `json:"strength"`
+	Dose     float64 `json:"dose"`
+	// Unit is the dose unit, e.g. "mg/kg", "mg" or "tablet".
+	Unit      string `json:"unit"`
+	Route     string `json:"route"`
+	Frequency string `json:"frequency"`
+	// DurationDays is the course length; 0 means a single dose or not given.
+	DurationDays int     `json:"duration_days"`
+	Quantity     float64 `json:"quantity"`
+	DispenseUnit string  `json:"dispense_unit"`
+	Instructions string  `json:"instructions,omitempty"`
+	// Text is the order as originally written, when it came as free text.
+	Text string `json:"text,omitempty"`
+}
+
+// UnmarshalJSON accepts either an order object or a free-text order string.
+// Notes stored before plans were structured hold strings, and the LLM still
+// falls back to them at times.
+func (m *MedicationOrder) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*m = MedicationOrder{Text: text}
+		return nil
+	}
+
+	type order MedicationOrder
+	var raw struct {
+		order
+		Dose     json.RawMessage `json:"dose"`
+		Quantity json.RawMessage `json:"quantity"`
+		Duration json.RawMessage `json:"duration_days"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = MedicationOrder(raw.order)
+
+	// The LLM often writes numbers as strings such as "10 mg".
+	var unit string
+	var err error
+	if m.Dose, unit, err = flexibleNumber(raw.Dose); err != nil {
+		return fmt.Errorf("invalid dose: %v", err)
+	}
+	if m.Unit == "" {
+		m.Unit = unit
+	}
+	if m.Quantity, unit, err = flexibleNumber(raw.Quantity); err != nil {
+		return fmt.Errorf("invalid quantity: %v", err)
+	}
+	if m.DispenseUnit == "" {
+		m.DispenseUnit = unit
+	}
+	days, _, err := flexibleNumber(raw.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration_days: %v", err)
+	}
+	m.DurationDays = int(days)
+	return nil
+}
+
+// flexibleNumber reads a JSON number, or a string such as "10 mg" which is
+// split into its number and unit. Strings without a leading number, such as
+// "as directed", read as zero.
+func flexibleNumber(data json.RawMessage) (float64, string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return 0, "", nil
+	}
+	var n float64
+	if err := json.Unmarshal(data, &n); err == nil {
+		return n, "", nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, "", err
+	}
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end < 0 {
+		end = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return 0, "", nil
+	}
+	return n, strings.TrimSpace(s[end:]), nil
+}
+
+// Normalize fills missing fields from the free text and maps the drug,
+// route, frequency and units to their dictionary spellings.
+func (m *MedicationOrder) Normalize() {
+	source := m.Text
+	if source == "" {
+		source = m.Drug
+	}
+	parsed := formulary.ParseOrder(source)
+
+	if drug, ok := formulary.Lookup(m.Drug); ok {
+		m.Drug, m.InDictionary = drug.Name, true
+	} else if parsed.Known {
+		m.Drug, m.InDictionary = parsed.Drug, true
+	} else if m.Drug == "" {
+		m.Drug = strings.TrimSpace(source)
+	}
+
+	if m.Strength == "" {
+		m.Strength = parsed.Strength
+	}
+	if m.Dose == 0 {
+		m.Dose, m.Unit = parsed.Dose, parsed.Unit
+	}
+	if m.Route == "" {
+		m.Route = parsed.Route
+	}
+	if m.Frequency == "" {
+		m.Frequency = parsed.Frequency
+	}
+	if m.DurationDays == 0 {
+		m.DurationDays = parsed.DurationDays
+	}
+	if m.Quantity == 0 {
+		m.Quantity, m.DispenseUnit = parsed.Quantity, parsed.DispenseUnit
+	}
+
+	m.Strength = strings.ToLower(strings.TrimSpace(m.Strength))
+	m.Unit = formulary.NormalizeUnit(m.Unit)
+	m.DispenseUnit = formulary.NormalizeUnit(m.DispenseUnit)
+	m.Route = formulary.NormalizeRoute(m.Route)
+	m.Frequency = formulary.NormalizeFrequency(m.Frequency)
+}
+
+// String renders the order on one line, e.g. "amoxicillin 250 mg: 12.5 mg/kg
+// oral q12h for 10 days, dispense 20 tablet".
+func (m MedicationOrder) String() string {
+	if m.Drug == "" {
+		return m.Text
+	}
+	var b strings.Builder
+	b.WriteString(m.Drug)
+	if m.Strength != "" {
+		b.WriteString(" " + m.Strength)
+	}
+	var parts []string
+	if m.Dose > 0 {
+		parts = append(parts, strings.TrimSpace(strconv.FormatFloat(m.Dose, 'f', -1, 64)+" "+m.Unit))
+	}
+	for _, p := range []string{m.Route, m.Frequency} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if m.DurationDays > 0 {
+		parts = append(parts, fmt.Sprintf("for %d days", m.DurationDays))
+	}
+	if len(parts) > 0 {
+		b.WriteString(": " + strings.Join(parts, " "))
+	}
+	if m.Quantity > 0 {
+		fmt.Fprintf(&b, ", dispense %s %s", strconv.FormatFloat(m.Quantity, 'f', -1, 64), m.DispenseUnit)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Medication converts the order to the medication list entry used in patient
+// summaries.
+func (m MedicationOrder) Medication() Medication {
+	dosage := m.Strength
+	if m.Dose > 0 {
+		dosage = strings.TrimSpace(strconv.FormatFloat(m.Dose, 'f', -1, 64) + " " + m.Unit)
+	}
+	return Medication{Name: m.Drug, Dosage: dosage, Frequency: m.Frequency}
+}
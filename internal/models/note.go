@@ -4,13 +4,29 @@ import "time"
 
 type Note struct {
 	ID         uint           `json:"id" gorm:"primaryKey"`
-	PatientID  uint           `json:"patient_id"`
-	Subjective SOAPSubjective `json:"subjective"`
-	Objective  SOAPObjective  `json:"objective"`
-	Assessment SOAPAssessment `json:"assessment"`
-	Plan       SOAPPlan       `json:"plan"`
+	PatientID  uint           `json:"patient_id" gorm:"index"`
+	Subjective SOAPSubjective `json:"subjective" gorm:"serializer:json"`
+	Objective  SOAPObjective  `json:"objective" gorm:"serializer:json"`
+	Assessment SOAPAssessment `json:"assessment" gorm:"serializer:json"`
+	Plan       SOAPPlan       `json:"plan" gorm:"serializer:json"`
 	VoiceData  []byte         `json:"voice_data"`
-	CreatedAt  time.Time      `json:"created_at"`
+	// Transcript is the consultation transcript the note was generated from.
+	Transcript string `json:"transcript"`
+	// SafetyWarnings are the medication safety problems found in the plan
+	// when the note was generated.
+	SafetyWarnings []SafetyWarning `json:"safety_warnings" gorm:"serializer:json"`
+	// Evidence links each extracted item to the transcript spans that
+	// support it; see LinkEvidence.
+	Evidence []ItemEvidence `json:"evidence" gorm:"serializer:json"`
+	// Status is draft until a veterinarian signs the note, which locks it.
+	Status string `json:"status" gorm:"index;default:draft"`
+	// Version is the number of the note's latest NoteVersion; notes stored
+	// before versioning start at 0.
+	Version   int        `json:"version"`
+	SignedBy  string     `json:"signed_by,omitempty"`
+	SignedAt  *time.Time `json:"signed_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
 }
 
 type SOAPSubjective struct {
@@ -22,11 +38,10 @@ type SOAPSubjective struct {
 }
 
 type SOAPObjective struct {
-	VitalSigns struct {
-		Temperature      string `json:"temperature"`
-		GeneralCondition string `json:"general_condition"`
-	} `json:"vital_signs"`
-	ExaminationFindings []string `json:"examination_findings"`
+	VitalSigns          VitalSigns `json:"vital_signs"`
+	ExaminationFindings []string   `json:"examination_findings"`
+	// ImageFindings holds clinical photo analyses attached to the note.
+	ImageFindings []ClinicalImageAnalysis `json:"image_findings,omitempty"`
 }
 
 type SOAPAssessment struct {
@@ -35,8 +50,25 @@ type SOAPAssessment struct {
 }
 
 type SOAPPlan struct {
-	ImmediateTreatment []string `json:"immediate_treatment"`
-	Medications        []string `json:"medications"`
-	FollowUp           string   `json:"follow_up"`
-	ClientEducation    []string `json:"client_education"`
+	ImmediateTreatment []string          `json:"immediate_treatment"`
+	Medications        []MedicationOrder `json:"medications"`
+	FollowUp           string            `json:"follow_up"`
+	ClientEducation    []string          `json:"client_education"`
+}
+
+// AttachImageFindings adds clinical image analyses to the objective section
+// and lists their observations among the examination findings.
+func (n *Note) AttachImageFindings(analyses ...ClinicalImageAnalysis) {
+	for i := range analyses {
+		n.Objective.ImageFindings = append(n.Objective.ImageFindings, analyses[i])
+		n.Objective.ExaminationFindings = append(n.Objective.ExaminationFindings, analyses[i].Findings()...)
+	}
+}
+
+// NormalizeMedications structures and normalizes the plan's medication
+// orders against the drug dictionary.
+func (n *Note) NormalizeMedications() {
+	for i := range n.Plan.Medications {
+		n.Plan.Medications[i].Normalize()
+	}
 }
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Note statuses.
+const (
+	NoteStatusDraft  = "draft"
+	NoteStatusSigned = "signed"
+)
+
+// Actions recorded in a note's version history.
+const (
+	NoteActionGenerated = "generated"
+	NoteActionEdited    = "edited"
+	// NoteActionRegenerated is a section rewritten by the model at a
+	// vet's request; the version's author is the vet who asked.
+	NoteActionRegenerated = "regenerated"
+	// NoteActionImagesAttached adds clinical image findings to the
+	// objective section.
+	NoteActionImagesAttached = "images_attached"
+	NoteActionSigned         = "signed"
+)
+
+// NoteAuthorAI is the author of generated drafts.
+const NoteAuthorAI = "ai"
+
+// SOAP note sections, by their JSON names.
+const (
+	SectionSubjective = "subjective"
+	SectionObjective  = "objective"
+	SectionAssessment = "assessment"
+	SectionPlan       = "plan"
+)
+
+// SOAPSections lists the sections in note order.
+var SOAPSections = []string{SectionSubjective, SectionObjective, SectionAssessment, SectionPlan}
+
+// NoteContent is the clinical content of a note, the part vets edit.
+type NoteContent struct {
+	Subjective SOAPSubjective `json:"subjective"`
+	Objective  SOAPObjective  `json:"objective"`
+	Assessment SOAPAssessment `json:"assessment"`
+	Plan       SOAPPlan       `json:"plan"`
+}
+
+// Content returns the note's clinical content.
+func (n *Note) Content() NoteContent {
+	return NoteContent{
+		Subjective: n.Subjective,
+		Objective:  n.Objective,
+		Assessment: n.Assessment,
+		Plan:       n.Plan,
+	}
+}
+
+// SetContent replaces the note's clinical content.
+func (n *Note) SetContent(content NoteContent) {
+	n.Subjective = content.Subjective
+	n.Objective = content.Objective
+	n.Assessment = content.Assessment
+	n.Plan = content.Plan
+}
+
+// IsSigned reports whether the note has been signed and is locked.
+func (n *Note) IsSigned() bool {
+	return n.Status == NoteStatusSigned
+}
+
+// NoteVersion is one entry in a note's history: the content after the change
+// and the fields that changed. Version 1 is the generated draft.
+type NoteVersion struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	NoteID  uint   `json:"note_id" gorm:"uniqueIndex:idx_note_versions_note_version"`
+	Version int    `json:"version" gorm:"uniqueIndex:idx_note_versions_note_version"`
+	Action  string `json:"action"`
+	// Author is the veterinarian or staff member who made the change, or
+	// NoteAuthorAI for the generated draft.
+	Author  string `json:"author"`
+	Comment string `json:"comment,omitempty"`
+	// Override is the reason given for signing over blocking safety
+	// warnings.
+	Override  string        `json:"override,omitempty"`
+	Changes   []FieldChange `json:"changes" gorm:"serializer:json"`
+	Content   NoteContent   `json:"content" gorm:"serializer:json"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// FieldChange is a changed field, named by its JSON path such as
+// "plan.follow_up" or "objective.vital_signs.temperature.value", with its
+// JSON values before and after. A missing side is null.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// DiffContent lists the fields that differ between two versions of a note's
+// content. Objects are compared field by field; lists and other values are
+// compared whole.
+func DiffContent(before, after NoteContent) ([]FieldChange, error) {
+	a, err := json.Marshal(before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := []FieldChange{}
+	diffJSON("", a, b, &changes)
+	return changes, nil
+}
+
+var jsonNull = json.RawMessage("null")
+
+func diffJSON(path string, before, after json.RawMessage, changes *[]FieldChange) {
+	if len(before) == 0 {
+		before = jsonNull
+	}
+	if len(after) == 0 {
+		after = jsonNull
+	}
+	var a, b map[string]json.RawMessage
+	if isJSONObject(before) && isJSONObject(after) &&
+		json.Unmarshal(before, &a) == nil && json.Unmarshal(after, &b) == nil {
+		keys := make([]string, 0, len(a)+len(b))
+		for k := range a {
+			keys = append(keys, k)
+		}
+		for k := range b {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field := k
+			if path != "" {
+				field = path + "." + k
+			}
+			diffJSON(field, a[k], b[k], changes)
+		}
+		return
+	}
+
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, before) == nil && json.Compact(&cb, after) == nil && bytes.Equal(ca.Bytes(), cb.Bytes()) {
+		return
+	}
+	*changes = append(*changes, FieldChange{Field: path, Before: before, After: after})
+}
+
+func isJSONObject(v json.RawMessage) bool {
+	v = bytes.TrimSpace(v)
+	return len(v) > 0 && v[0] == '{'
+}
@@ -1,17 +1,37 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Patient struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`
-	Name         string    `json:"name"`
-	Species      string    `json:"species"`
-	Breed        string    `json:"breed"`
-	DateOfBirth  time.Time `json:"date_of_birth"`
-	Allergies    []Allergy `json:"allergies" gorm:"many2many:patient_allergies"`
-	MedicalNotes []Note    `json:"medical_notes" gorm:"foreignKey:PatientID"`
+	ID                    uint                   `json:"id" gorm:"primaryKey"`
+	Name                  string                 `json:"name"`
+	ClinicID              string                 `json:"clinic_id" gorm:"index"`
+	Species               string                 `json:"species"`
+	Breed                 string                 `json:"breed"`
+	BreedComposition      []BreedCandidate       `json:"breed_composition" gorm:"serializer:json"`
+	HealthPredispositions []HealthPredisposition `json:"health_predispositions" gorm:"serializer:json"`
+	DateOfBirth           time.Time              `json:"date_of_birth"`
+	Allergies             []PatientAllergy       `json:"allergies" gorm:"foreignKey:PatientID"`
+	MedicalNotes          []Note                 `json:"medical_notes" gorm:"foreignKey:PatientID"`
 }
 
+// ApplyBreedDetection records a breed detection result on the patient.
+func (p *Patient) ApplyBreedDetection(d *BreedDetection) {
+	if d.Species != "" && d.Species != SpeciesOther {
+		p.Species = d.Species
+	}
+	if label := d.BreedLabel(); label != "" {
+		p.Breed = label
+	}
+	p.BreedComposition = d.Candidates
+	p.HealthPredispositions = d.HealthPredispositions
+}
+
+// Allergy is a known allergen. Severity and Description are the defaults
+// used when the allergy is first recorded for a patient.
 type Allergy struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
 	Name        string `json:"name"`
@@ -19,20 +39,50 @@ type Allergy struct {
 	Description string `json:"description"`
 }
 
+// PatientAllergy is an allergy recorded for one patient. Severity and
+// description belong to the patient, since the same allergen can be mild in
+// one animal and life-threatening in another.
+type PatientAllergy struct {
+	PatientID   uint `gorm:"primaryKey"`
+	AllergyID   uint `gorm:"primaryKey"`
+	Allergy     Allergy
+	Severity    string
+	Description string
+}
+
+func (a PatientAllergy) Name() string {
+	return a.Allergy.Name
+}
+
+// MarshalJSON renders the allergy as the allergen with the patient's
+// severity and description.
+func (a PatientAllergy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Allergy{
+		ID:          a.AllergyID,
+		Name:        a.Allergy.Name,
+		Severity:    a.Severity,
+		Description: a.Description,
+	})
+}
+
 type PatientSummary struct {
 	ID             uint           `json:"id" gorm:"primaryKey"`
+	PatientID      uint           `json:"patient_id" gorm:"uniqueIndex"`
 	Name           string         `json:"name"`
 	Breed          string         `json:"breed"`
 	DateOfBirth    time.Time      `json:"date_of_birth"`
-	KeyConditions  []string       `json:"key_conditions"`
-	RecentVisits   []Visit        `json:"recent_visits"`
-	Medications    []Medication   `json:"current_medications"`
-	Alerts         []string       `json:"alerts"`
-	PreventiveCare PreventiveCare `json:"preventive_care"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	CreatedBy      string         `json:"created_by"`
-	UpdatedBy      string         `json:"updated_by"`
+	KeyConditions  []string       `json:"key_conditions" gorm:"serializer:json"`
+	RecentVisits   []Visit        `json:"recent_visits" gorm:"serializer:json"`
+	Medications    []Medication   `json:"current_medications" gorm:"serializer:json"`
+	Alerts         []string       `json:"alerts" gorm:"serializer:json"`
+	PreventiveCare PreventiveCare `json:"preventive_care" gorm:"serializer:json"`
+	// Fingerprint identifies the stored history the summary was generated
+	// from; a different fingerprint means the summary is stale.
+	Fingerprint string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   string    `json:"created_by"`
+	UpdatedBy   string    `json:"updated_by"`
 }
 
 type Visit struct {
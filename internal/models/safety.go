@@ -0,0 +1,48 @@
+package models
+
+// Safety warning types.
+const (
+	SafetyAllergy          = "allergy"
+	SafetyDose             = "dose"
+	SafetyContraindication = "contraindication"
+	SafetyInteraction      = "interaction"
+)
+
+// Safety warning severities, from most to least serious.
+const (
+	SafetyCritical = "critical"
+	SafetyMajor    = "major"
+	SafetyModerate = "moderate"
+	SafetyMinor    = "minor"
+)
+
+// SafetyWarning is a problem found when checking a plan's medications against
+// the patient's record. Blocking warnings must be resolved by a veterinarian
+// before the plan is acted on.
+type SafetyWarning struct {
+	Type       string `json:"type"`
+	Severity   string `json:"severity"`
+	Blocking   bool   `json:"blocking"`
+	Medication string `json:"medication"`
+	Drug       string `json:"drug,omitempty"`
+	// Match explains how the warning was raised, e.g. "class" for a
+	// drug-class allergy match.
+	Match   string `json:"match,omitempty"`
+	Allergy string `json:"allergy,omitempty"`
+	// Interacts lists the other drugs of an interaction warning.
+	Interacts []string `json:"interacts_with,omitempty"`
+	// SafeRange is the computed dose range for the patient, for dose
+	// warnings.
+	SafeRange string `json:"safe_range,omitempty"`
+	Message   string `json:"message"`
+}
+
+// HasBlockingWarnings reports whether any safety warning on the note blocks.
+func (n *Note) HasBlockingWarnings() bool {
+	for _, w := range n.SafetyWarnings {
+		if w.Blocking {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"vet-tails/ai/internal/formulary"
+)
+
+// Vital sign statuses against the species normal range.
+const (
+	VitalNormal = "normal"
+	VitalLow    = "low"
+	VitalHigh   = "high"
+)
+
+// VitalSigns are the vital signs recorded in the objective section.
+type VitalSigns struct {
+	Temperature     Measurement `json:"temperature"`
+	HeartRate       Measurement `json:"heart_rate"`
+	RespiratoryRate Measurement `json:"respiratory_rate"`
+	Weight          Measurement `json:"weight"`
+	// MucousMembranes describes color and moisture, e.g. "pink, moist".
+	MucousMembranes string `json:"mucous_membranes"`
+	// CapillaryRefillTime is in seconds.
+	CapillaryRefillTime Measurement `json:"capillary_refill_time"`
+	// Hydration is the clinical estimate, e.g. "normal" or "5% dehydrated".
+	Hydration string `json:"hydration"`
+	// PainScore is on a 0–10 scale.
+	PainScore Measurement `json:"pain_score"`
+	// BodyConditionScore is on the 9-point scale.
+	BodyConditionScore Measurement `json:"body_condition_score"`
+	GeneralCondition   string      `json:"general_condition"`
+	// Abnormal describes each value outside the species normal range.
+	Abnormal []string `json:"abnormal,omitempty"`
+}
+
+// vitalRange is a species normal range, in the unit given.
+type vitalRange struct {
+	min, max float64
+	unit     string
+}
+
+type speciesVitals struct {
+	temperature, heartRate, respiratoryRate, crt vitalRange
+}
+
+// vitalRanges are adult resting reference ranges.
+var vitalRanges = map[string]speciesVitals{
+	formulary.SpeciesDog: {
+		temperature:     vitalRange{37.5, 39.2, UnitCelsius},
+		heartRate:       vitalRange{60, 160, UnitBeatsPerMin},
+		respiratoryRate: vitalRange{10, 30, UnitBreathsPerM},
+		crt:             vitalRange{0, 2, UnitSecond},
+	},
+	formulary.SpeciesCat: {
+		temperature:     vitalRange{37.7, 39.2, UnitCelsius},
+		heartRate:       vitalRange{140, 220, UnitBeatsPerMin},
+		respiratoryRate: vitalRange{20, 40, UnitBreathsPerM},
+		crt:             vitalRange{0, 2, UnitSecond},
+	},
+}
+
+var (
+	painRange = vitalRange{0, 3, UnitPainScale}
+	bcsRange  = vitalRange{4, 5, UnitBCSScale}
+)
+
+// Normalize converts every measurement to its canonical SI unit, keeping
+// the original, and rejects impossible values.
+func (v *VitalSigns) Normalize() {
+	v.Temperature.normalize(temperatureQuantity)
+	v.HeartRate.normalize(heartRateQuantity)
+	v.RespiratoryRate.normalize(respiratoryRateQuantity)
+	v.Weight.normalize(weightQuantity)
+	v.CapillaryRefillTime.normalize(crtQuantity)
+	v.PainScore.normalize(painQuantity)
+	v.BodyConditionScore.normalize(bcsQuantity)
+}
+
+// WeightKg returns the recorded weight in kilograms. The weight is
+// normalized on a copy, so notes stored before normalization read correctly.
+func (v *VitalSigns) WeightKg() (float64, bool) {
+	w := v.Weight
+	w.normalize(weightQuantity)
+	if w.Value == nil {
+		return 0, false
+	}
+	return *w.Value, true
+}
+
+// Evaluate flags normalized values outside the species normal ranges and
+// lists them in Abnormal. Pain and body condition are flagged for any
+// species.
+func (v *VitalSigns) Evaluate(species string) {
+	species = formulary.NormalizeSpecies(species)
+	v.Abnormal = nil
+
+	if ranges, ok := vitalRanges[species]; ok {
+		v.flag("temperature", &v.Temperature, ranges.temperature, species)
+		v.flag("heart rate", &v.HeartRate, ranges.heartRate, species)
+		v.flag("respiratory rate", &v.RespiratoryRate, ranges.respiratoryRate, species)
+		v.flag("capillary refill time", &v.CapillaryRefillTime, ranges.crt, species)
+	}
+	v.flag("pain score", &v.PainScore, painRange, "")
+	v.flag("body condition score", &v.BodyConditionScore, bcsRange, "")
+}
+
+func (v *VitalSigns) flag(name string, m *Measurement, r vitalRange, species string) {
+	if m.Value == nil {
+		return
+	}
+	value := *m.Value
+	m.NormalRange = fmt.Sprintf("%g–%s", r.min, formatQuantity(r.max, r.unit))
+
+	switch {
+	case value < r.min:
+		m.Status = VitalLow
+	case value > r.max:
+		m.Status = VitalHigh
+	default:
+		m.Status = VitalNormal
+		return
+	}
+	direction := "above"
+	if m.Status == VitalLow {
+		direction = "below"
+	}
+	reference := "the normal range"
+	if species != "" {
+		reference = "the normal range for a " + species
+	}
+	v.Abnormal = append(v.Abnormal, fmt.Sprintf("%s %s is %s %s (%s)", name, m.Original, direction, reference, m.NormalRange))
+}
@@ -1,6 +1,9 @@
 package router
 
 import (
+	"log"
+	"vet-tails/ai/internal/config"
+	"vet-tails/ai/internal/database"
 	"vet-tails/ai/internal/handlers"
 	"vet-tails/ai/internal/services"
 
@@ -9,36 +12,93 @@ import (
 
 func SetupRouter() *gin.Engine {
 	router := gin.Default()
+	cfg := config.LoadConfig()
 
 	// Middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	// router.Use(middleware.Authentication())
 
+	db := database.InitDB(cfg.DatabaseURL)
+
 	// // Services
 	// analysisService := services.NewAnalysisService(db)
-	soapService := services.NewSOAPService("http://localhost:11434")
-	// llavaService := services.NewLlavaService("http://localhost:11434")
+	soapService := services.NewSOAPService(cfg.OllamaURL, cfg.OllamaModel, cfg.ModelContextTokens)
+	llavaService := services.NewLlavaService(cfg.OllamaURL)
+	embeddingCache, err := services.NewEmbeddingCache(cfg.EmbeddingCacheDir, cfg.EmbeddingCacheSize)
+	if err != nil {
+		log.Fatalf("Failed to open embedding cache: %v", err)
+	}
+	embedder, err := services.NewEmbedder(cfg.OllamaURL, cfg.EmbeddingModel, cfg.EmbeddingDimension, embeddingCache)
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	// Collections migrated to another model keep using it
+	newEmbedder := func(model string, dimension int) (services.Embedder, error) {
+		return services.NewEmbedder(cfg.OllamaURL, model, dimension, embeddingCache)
+	}
+	vectorStore, err := services.NewVectorStore(cfg.VectorStore, cfg.ChromaURL, cfg.LocalVectorDir)
+	if err != nil {
+		log.Fatalf("Failed to create vector store: %v", err)
+	}
 	handler := handlers.Handler{
-		// DB:          db,
-		SoapService: soapService,
-		// LlavaService: llavaService,
+		DB:                   db,
+		SoapService:          soapService,
+		Notes:                services.NewNoteService(db, soapService),
+		LlavaService:         llavaService,
+		ClinicalImageService: services.NewClinicalImageService(llavaService),
+		PatientSummaries:     services.NewPatientSummaryService(db, soapService),
+		FollowUps:            services.NewFollowUpService(db, soapService),
+		KnowledgeBase:        services.NewKnowledgeBaseService(vectorStore, embedder, newEmbedder),
+		EmbeddingCache:       embeddingCache,
+		OllamaURL:            cfg.OllamaURL,
+		IngestRoot:           cfg.IngestRoot,
+		MaxUploadSize:        cfg.MaxUploadSize,
+		MaxImageDimension:    cfg.MaxImageDimension,
 	}
 
 	// Routes
 	api := router.Group("/api/v1")
 	{
 		// api.POST("/soap", handlers.CreateSOAPNote)
-		// api.GET("/patients/:id/history", handlers.GetPatientHistory)
 		// api.POST("/invoice/generate", handlers.GenerateInvoice)
 		// api.GET("/recommendations", handlers.GetRecommendations)
 		api.POST("/soap", handler.CreateSOAPNote)
-		// api.POST("/breed", handler.DetectBreed)
-		// api.POST("/summary", handler.GeneratePatientSummary)
-		// api.POST("/activity", handler.GeneratePetActivityLog)
+		api.POST("/breed", handler.DetectBreed)
+		api.POST("/image-analysis", handler.AnalyzeClinicalImage)
+		api.POST("/patients", handler.CreatePatient)
+		api.GET("/patients", handler.ListPatients)
+		api.GET("/patients/:id", handler.GetPatient)
+		api.PATCH("/patients/:id", handler.UpdatePatient)
+		api.GET("/patients/:id/history", handler.GetPatientHistory)
+		api.GET("/patients/:id/allergies", handler.GetPatientAllergies)
+		api.POST("/patients/:id/allergies", handler.AddPatientAllergy)
+		api.DELETE("/patients/:id/allergies/:allergyId", handler.RemovePatientAllergy)
+		api.GET("/allergies", handler.ListAllergies)
+		api.POST("/patients/:id/body-condition", handler.EstimateBodyCondition)
+		api.GET("/patients/:id/body-condition", handler.GetBodyConditionHistory)
+		api.POST("/patients/:id/summary", handler.GeneratePatientSummary)
+		api.POST("/patients/:id/activity", handler.GeneratePetActivityLog)
+		api.GET("/patients/:id/activity", handler.GetActivityTimeline)
+		api.GET("/tasks", handler.ListFollowUpTasks)
+		api.GET("/tasks/:id", handler.GetFollowUpTask)
+		api.POST("/tasks/:id/acknowledge", handler.AcknowledgeFollowUpTask)
+		api.POST("/tasks/:id/resolve", handler.ResolveFollowUpTask)
+		api.POST("/dose/calculate", handler.CalculateDose)
+		api.POST("/interactions/check", handler.CheckInteractions)
+		api.GET("/notes/:id", handler.GetNote)
+		api.PATCH("/notes/:id", handler.UpdateNote)
+		api.POST("/notes/:id/regenerate", handler.RegenerateNoteSection)
+		api.GET("/notes/:id/versions", handler.GetNoteVersions)
+		api.GET("/notes/:id/diff", handler.GetNoteDiff)
+		api.POST("/notes/:id/sign", handler.SignNote)
 		api.POST("/upload-pdf", handler.UploadPDFHandler)
+		api.POST("/ingest", handler.IngestHandler)
 		api.GET("/collection", handler.GetCollection)
 		api.POST("/collection", handler.CreateCollection)
+		api.POST("/collection/reembed", handler.StartReembed)
+		api.GET("/collection/reembed/:id", handler.GetReembedJob)
+		api.GET("/embedding-cache/stats", handler.GetEmbeddingCacheStats)
 		api.POST("/search", handler.SearchKnowledgeBase)
 	}
 
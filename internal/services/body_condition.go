@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"vet-tails/ai/internal/models"
+)
+
+// bodyConditionAnswer is the model's raw answer. Score is a float because
+// models sometimes answer with half points or on the 5-point scale.
+type bodyConditionAnswer struct {
+	Score      float64                        `json:"score"`
+	Scale      int                            `json:"scale"`
+	Confidence float64                        `json:"confidence"`
+	Reasoning  string                         `json:"reasoning"`
+	Indicators models.BodyConditionIndicators `json:"indicators"`
+}
+
+// MaxBodyConditionImages caps how many photos are scored together.
+const MaxBodyConditionImages = 4
+
+// EstimateBodyCondition scores body condition on the 9-point scale from side
+// and top-view photos. views labels each image; species tailors the criteria.
+func (s *LlavaService) EstimateBodyCondition(images []string, views []string, species string) (*models.BodyConditionRecord, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images provided")
+	}
+	if len(images) > MaxBodyConditionImages {
+		return nil, fmt.Errorf("at most %d images can be scored together", MaxBodyConditionImages)
+	}
+
+	var imageList strings.Builder
+	for i := range images {
+		view := "unspecified view"
+		if i < len(views) && strings.TrimSpace(views[i]) != "" {
+			view = strings.TrimSpace(views[i])
+		}
+		fmt.Fprintf(&imageList, "    - Image %d: %s\n", i+1, view)
+	}
+	if species == "" {
+		species = "animal"
+	}
+
+	prompt := fmt.Sprintf(`As a veterinary AI assistant, estimate the body condition score of this %s
+    on the 9-point scale (1 = emaciated, 4-5 = ideal, 9 = grossly obese) from
+    the following photos:
+%s
+    Base the score on:
+    - Rib visibility and the fat cover over the ribs
+    - Waist when viewed from above
+    - Abdominal tuck when viewed from the side
+    - Fat deposits over the lumbar area, tail base, face, limbs or belly
+    Explain your reasoning and rate your confidence between 0 and 1. Coat
+    length and posture can hide body shape; lower your confidence if they do.
+
+    Respond only with valid JSON matching this example:
+    {
+        "score": 6,
+        "scale": 9,
+        "confidence": 0.7,
+        "reasoning": "short explanation",
+        "indicators": {
+            "rib_visibility": "not visible, palpable with slight pressure",
+            "waist_from_above": "discernible but not prominent",
+            "abdominal_tuck": "slight",
+            "fat_deposits": ["lumbar area"]
+        }
+    }`, species, imageList.String())
+
+	var answer bodyConditionAnswer
+	if err := s.generate(prompt, images, &answer); err != nil {
+		return nil, fmt.Errorf("error parsing body condition score: %v", err)
+	}
+	if answer.Score <= 0 {
+		return nil, fmt.Errorf("error parsing body condition score: no score given")
+	}
+	score := answer.Score
+	if answer.Scale == 5 {
+		score = score*2 - 1
+	}
+
+	record := &models.BodyConditionRecord{
+		Score:      int(math.Round(score)),
+		Confidence: answer.Confidence,
+		Reasoning:  answer.Reasoning,
+		Indicators: answer.Indicators,
+		Views:      views,
+		Model:      s.model,
+		RecordedAt: time.Now(),
+	}
+	record.Normalize()
+	return record, nil
+}
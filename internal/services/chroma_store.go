@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	chroma "github.com/amikos-tech/chroma-go"
+	chhttp "github.com/amikos-tech/chroma-go/pkg/commons/http"
+	"github.com/amikos-tech/chroma-go/types"
+)
+
+// ChromaStore is the VectorStore backed by a ChromaDB server.
+type ChromaStore struct {
+	client *chroma.Client
+}
+
+var _ VectorStore = (*ChromaStore)(nil)
+
+func NewChromaStore(baseURL string) (*ChromaStore, error) {
+	client, err := chroma.NewClient(chroma.WithBasePath(baseURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ChromaDB client: %v", err)
+	}
+	return &ChromaStore{client: client}, nil
+}
+
+// precomputedEmbeddings satisfies chroma-go's EmbeddingFunction for calls that
+// always pass their own embeddings. chroma-go still invokes it with the empty
+// list of query texts.
+type precomputedEmbeddings struct{}
+
+func (precomputedEmbeddings) EmbedDocuments(ctx context.Context, texts []string) ([]*types.Embedding, error) {
+	if len(texts) > 0 {
+		return nil, fmt.Errorf("vector store expects precomputed embeddings")
+	}
+	return nil, nil
+}
+
+func (precomputedEmbeddings) EmbedQuery(ctx context.Context, text string) (*types.Embedding, error) {
+	return nil, fmt.Errorf("vector store expects precomputed embeddings")
+}
+
+func (e precomputedEmbeddings) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(e, ctx, records, force)
+}
+
+func (s *ChromaStore) collection(ctx context.Context, name string) (*chroma.Collection, error) {
+	collection, err := s.client.GetCollection(ctx, name, precomputedEmbeddings{})
+	if isChromaNotFound(err) {
+		return nil, fmt.Errorf("%w: %s: %v", ErrCollectionNotFound, name, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection %s: %v", name, err)
+	}
+	return collection, nil
+}
+
+// isChromaNotFound reports whether err is Chroma's answer for a missing
+// collection, as opposed to a failure to reach the server. Older servers
+// report it as a 500 ValueError rather than a 404.
+func isChromaNotFound(err error) bool {
+	var chErr *chhttp.ChromaError
+	if !errors.As(err, &chErr) {
+		return false
+	}
+	return chErr.ErrorCode == http.StatusNotFound ||
+		chErr.ErrorID == "NotFoundError" ||
+		strings.Contains(chErr.Message, "does not exist")
+}
+
+func toCollection(c *chroma.Collection) *Collection {
+	return &Collection{
+		ID:       c.ID,
+		Name:     c.Name,
+		Metadata: c.Metadata,
+	}
+}
+
+func (s *ChromaStore) CreateCollection(ctx context.Context, name string, metadata map[string]interface{}) (*Collection, error) {
+	collection, err := s.client.CreateCollection(ctx, name, metadata, true, precomputedEmbeddings{}, types.L2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collection: %v", err)
+	}
+	return toCollection(collection), nil
+}
+
+func (s *ChromaStore) GetCollection(ctx context.Context, name string) (*Collection, error) {
+	collection, err := s.collection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return toCollection(collection), nil
+}
+
+func (s *ChromaStore) DeleteCollection(ctx context.Context, name string) error {
+	if _, err := s.client.DeleteCollection(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete collection: %v", err)
+	}
+	return nil
+}
+
+func (s *ChromaStore) UpdateCollection(ctx context.Context, name string, newName string, metadata map[string]interface{}) error {
+	collection, err := s.collection(ctx, name)
+	if err != nil {
+		return err
+	}
+	m := mutableMetadata(metadata)
+	if _, err := collection.Update(ctx, newName, &m); err != nil {
+		return fmt.Errorf("failed to update collection: %v", err)
+	}
+	return nil
+}
+
+func (s *ChromaStore) Count(ctx context.Context, name string) (int, error) {
+	collection, err := s.collection(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	count, err := collection.Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count collection: %v", err)
+	}
+	return int(count), nil
+}
+
+func (s *ChromaStore) Add(ctx context.Context, name string, records []VectorRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	collection, err := s.collection(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	embeddings := make([]*types.Embedding, len(records))
+	metadatas := make([]map[string]interface{}, len(records))
+	documents := make([]string, len(records))
+	ids := make([]string, len(records))
+	for i, r := range records {
+		embeddings[i] = types.NewEmbeddingFromFloat32(r.Embedding)
+		metadatas[i] = r.Metadata
+		documents[i] = r.Document
+		ids[i] = r.ID
+	}
+	if _, err := collection.Add(ctx, embeddings, metadatas, documents, ids); err != nil {
+		return fmt.Errorf("failed to add document: %v", err)
+	}
+	return nil
+}
+
+func (s *ChromaStore) Get(ctx context.Context, name string, where map[string]interface{}, limit int, offset int) ([]VectorRecord, error) {
+	collection, err := s.collection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		// chroma-go always sends a limit, so ask for everything explicitly.
+		count, err := collection.Count(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count collection: %v", err)
+		}
+		if count == 0 {
+			return nil, nil
+		}
+		limit = int(count)
+	}
+
+	opts := []types.CollectionQueryOption{
+		types.WithLimit(int32(limit)),
+		types.WithOffset(int32(offset)),
+		types.WithInclude(types.IDocuments, types.IMetadatas),
+	}
+	if len(where) > 0 {
+		opts = append(opts, types.WithWhereMap(where))
+	}
+	res, err := collection.GetWithOptions(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents: %v", err)
+	}
+
+	records := make([]VectorRecord, len(res.Ids))
+	for i, id := range res.Ids {
+		records[i] = VectorRecord{ID: id}
+		if i < len(res.Documents) {
+			records[i].Document = res.Documents[i]
+		}
+		if i < len(res.Metadatas) {
+			records[i].Metadata = res.Metadatas[i]
+		}
+	}
+	return records, nil
+}
+
+func (s *ChromaStore) Delete(ctx context.Context, name string, ids []string, where map[string]interface{}) error {
+	collection, err := s.collection(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(where) == 0 {
+		where = nil
+	}
+	if _, err := collection.Delete(ctx, ids, where, nil); err != nil {
+		return fmt.Errorf("failed to delete documents: %v", err)
+	}
+	return nil
+}
+
+func (s *ChromaStore) Query(ctx context.Context, name string, embedding []float32, nResults int, where map[string]interface{}) ([]VectorMatch, error) {
+	collection, err := s.collection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []types.CollectionQueryOption{
+		types.WithQueryEmbedding(types.NewEmbeddingFromFloat32(embedding)),
+		types.WithNResults(int32(nResults)),
+	}
+	if len(where) > 0 {
+		opts = append(opts, types.WithWhereMap(where))
+	}
+	res, err := collection.QueryWithOptions(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying ChromaDB: %v", err)
+	}
+	if len(res.Ids) == 0 {
+		return nil, nil
+	}
+
+	matches := make([]VectorMatch, len(res.Ids[0]))
+	for i, id := range res.Ids[0] {
+		matches[i] = VectorMatch{VectorRecord: VectorRecord{ID: id}}
+		if len(res.Documents) > 0 && i < len(res.Documents[0]) {
+			matches[i].Document = res.Documents[0][i]
+		}
+		if len(res.Metadatas) > 0 && i < len(res.Metadatas[0]) {
+			matches[i].Metadata = res.Metadatas[0][i]
+		}
+		if len(res.Distances) > 0 && i < len(res.Distances[0]) {
+			matches[i].Distance = res.Distances[0][i]
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	chhttp "github.com/amikos-tech/chroma-go/pkg/commons/http"
+)
+
+func TestIsChromaNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"404", &chhttp.ChromaError{ErrorCode: 404, ErrorID: "NotFoundError", Message: "Collection docs not found"}, true},
+		{"legacy value error", &chhttp.ChromaError{ErrorCode: 500, ErrorID: "ValueError", Message: "Collection docs does not exist."}, true},
+		{"connection refused", &chhttp.ChromaError{ErrorID: "unknown", Message: "dial tcp 127.0.0.1:8000: connect: connection refused"}, false},
+		{"server error", &chhttp.ChromaError{ErrorCode: 500, ErrorID: "InternalError", Message: "disk full"}, false},
+		{"plain error", errors.New("timeout"), false},
+		{"wrapped", fmt.Errorf("get: %w", &chhttp.ChromaError{ErrorCode: 404}), true},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isChromaNotFound(tt.err); got != tt.want {
+				t.Errorf("isChromaNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -5,15 +5,12 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 
-	chroma "github.com/amikos-tech/chroma-go"
-	"github.com/amikos-tech/chroma-go/pkg/embeddings/ollama"
-	"github.com/amikos-tech/chroma-go/types"
 	"github.com/ledongthuc/pdf"
 )
 
 const (
-	ChromaURL = "http://localhost:8000/api/v1"
 	// Adjust these constants based on your needs
 	MaxChunkSize    = 1500  // Maximum characters per chunk
 	MinChunkSize    = 500   // Minimum characters per chunk
@@ -49,14 +46,20 @@ type AddRequest struct {
 	IDs        []string    `json:"ids"`
 }
 
-func readPDF(filepath string) (string, error) {
+func readPDF(filepath string) (text string, err error) {
+	// The PDF reader panics on some malformed inputs.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrMalformedPDF, r)
+		}
+	}()
+
 	f, r, err := pdf.Open(filepath)
 	if err != nil {
 		return "", fmt.Errorf("error opening PDF: %v", err)
 	}
 	defer f.Close()
 
-	var text string
 	totalPage := r.NumPage()
 
 	for pageIndex := 1; pageIndex <= totalPage; pageIndex++ {
@@ -75,74 +78,136 @@ func readPDF(filepath string) (string, error) {
 	return text, nil
 }
 
-func ChromaClient() *chroma.Client {
-	client, err := chroma.NewClient()
-	if err != nil {
-		log.Fatalf("Error creating client: %s \n", err)
-		return nil
-	}
-	return client
-}
-
 type Collection struct {
 	ID       string                 `json:"id"`
 	Name     string                 `json:"name"`
 	Metadata map[string]interface{} `json:"metadata"`
 }
 
-func GetCollection(ctx context.Context, collectionName string) *Collection {
-	client := ChromaClient()
+// EmbedderFactory builds an embedder for the model a collection was
+// populated with.
+type EmbedderFactory func(model string, dimension int) (Embedder, error)
+
+// KnowledgeBaseService stores and searches reference documents in a vector
+// store. New collections use the configured embedder; existing ones are
+// queried and written with the model recorded in their metadata.
+type KnowledgeBaseService struct {
+	store       VectorStore
+	embedder    Embedder
+	newEmbedder EmbedderFactory
+	mu          sync.Mutex
+	// embedders caches the embedders built by newEmbedder, keyed by model
+	// and dimension.
+	embedders map[string]Embedder
+	jobs      *reembedJobs
+}
 
-	ef, err := ollama.NewOllamaEmbeddingFunction(ollama.WithBaseURL("http://127.0.0.1:11434"), ollama.WithModel("nomic-embed-text"))
+// NewKnowledgeBaseService creates a knowledge base whose new collections use
+// embedder. Collections populated with another model get an embedder from
+// newEmbedder; when it is nil they are refused with ErrEmbedderMismatch.
+func NewKnowledgeBaseService(store VectorStore, embedder Embedder, newEmbedder EmbedderFactory) *KnowledgeBaseService {
+	return &KnowledgeBaseService{
+		store:       store,
+		embedder:    embedder,
+		newEmbedder: newEmbedder,
+		embedders:   make(map[string]Embedder),
+		jobs:        newReembedJobs(),
+	}
+}
+
+func (s *KnowledgeBaseService) Embedder() Embedder {
+	return s.embedder
+}
+
+// embedderFor returns the embedder matching the model recorded in a
+// collection's metadata.
+func (s *KnowledgeBaseService) embedderFor(collectionName string, metadata map[string]interface{}) (Embedder, error) {
+	err := checkEmbedder(collectionName, metadata, s.embedder)
+	if err == nil {
+		return s.embedder, nil
+	}
+	if s.newEmbedder == nil {
+		return nil, err
+	}
+
+	model, dimension := collectionEmbedder(metadata)
+	key := fmt.Sprintf("%s/%d", model, dimension)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.embedders[key]; ok {
+		return e, nil
+	}
+	e, err := s.newEmbedder(model, dimension)
 	if err != nil {
-		fmt.Printf("Error creating Ollama embedding function: %s \n", err)
+		return nil, fmt.Errorf("failed to create embedder for %s: %v", collectionName, err)
 	}
+	s.embedders[key] = e
+	return e, nil
+}
 
-	// Try to get existing collection
-	collection, err := client.GetCollection(ctx, collectionName, ef)
+// openCollection fetches a collection along with the embedder it was
+// populated with.
+func (s *KnowledgeBaseService) openCollection(ctx context.Context, collectionName string) (*Collection, Embedder, error) {
+	collection, err := s.store.GetCollection(ctx, collectionName)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return nil, nil, fmt.Errorf("failed to get collection: %v", err)
 	}
-	return &Collection{
-		ID:       collection.ID,
-		Name:     collection.Name,
-		Metadata: collection.Metadata,
+	embedder, err := s.embedderFor(collectionName, collection.Metadata)
+	if err != nil {
+		return nil, nil, err
 	}
+	return collection, embedder, nil
 }
 
-func CreateCollection(ctx context.Context, collectionName string) (*Collection, error) {
-	log.Printf("📝 Attempting to create collection: %s", collectionName)
-
-	client := ChromaClient()
-	if client == nil {
-		log.Println("❌ Failed to create ChromaDB client")
-		return nil, fmt.Errorf("failed to create ChromaDB client")
+// openForWrite opens a collection for ingestion, refusing while it is being
+// re-embedded. With create set, a missing collection is created with the
+// configured embedder. The returned function must be called when the write
+// is done.
+func (s *KnowledgeBaseService) openForWrite(ctx context.Context, collectionName string, create bool) (Embedder, func(), error) {
+	done, err := s.jobs.beginWrite(collectionName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if create {
+		if _, err := s.store.CreateCollection(ctx, collectionName, embedderMetadata(s.embedder)); err != nil {
+			done()
+			return nil, nil, fmt.Errorf("failed to create collection: %v", err)
+		}
+	}
+	_, embedder, err := s.openCollection(ctx, collectionName)
+	if err != nil {
+		done()
+		return nil, nil, err
 	}
-	log.Println("✅ ChromaDB client created successfully")
+	return embedder, done, nil
+}
 
-	ef, err := ollama.NewOllamaEmbeddingFunction(
-		ollama.WithBaseURL("http://127.0.0.1:11434"),
-		ollama.WithModel("nomic-embed-text"),
-	)
+func (s *KnowledgeBaseService) GetCollection(ctx context.Context, collectionName string) *Collection {
+	// Try to get existing collection
+	collection, err := s.store.GetCollection(ctx, collectionName)
 	if err != nil {
-		log.Printf("❌ Error creating Ollama embedding function: %s\n", err)
-		return nil, fmt.Errorf("failed to create Ollama embedding function")
+		fmt.Println(err)
+		return nil
 	}
-	log.Println("✅ ChromaDB client created successfully")
+	return collection
+}
+
+func (s *KnowledgeBaseService) CreateCollection(ctx context.Context, collectionName string) (*Collection, error) {
+	log.Printf("📝 Attempting to create collection: %s", collectionName)
 
-	collection, err := client.CreateCollection(ctx, collectionName, nil, true, ef, types.L2)
+	collection, err := s.store.CreateCollection(ctx, collectionName, embedderMetadata(s.embedder))
 	if err != nil {
 		log.Printf("❌ Error creating collection: %s\n", err)
-		return nil, fmt.Errorf("failed to create collection: %v", err)
+		return nil, err
+	}
+	// An existing collection may have been built with another model
+	if _, err := s.embedderFor(collectionName, collection.Metadata); err != nil {
+		log.Printf("❌ %v\n", err)
+		return nil, err
 	}
 	log.Printf("✅ Successfully created new collection: %s", collectionName)
 
-	return &Collection{
-		ID:       collection.ID,
-		Name:     collection.Name,
-		Metadata: collection.Metadata,
-	}, nil
+	return collection, nil
 }
 
 func splitContent(content string) []string {
@@ -221,117 +286,36 @@ func splitIntoSentences(text string) []string {
 	return sentences
 }
 
-func generateEmbeddings(ctx context.Context, content string) (*types.Embedding, error) {
-	ef, err := ollama.NewOllamaEmbeddingFunction(
-		ollama.WithBaseURL("http://127.0.0.1:11434"),
-		ollama.WithModel("nomic-embed-text"),
-	)
+// AddDocuments ingests a single file into the collection, recording it under
+// the given source name.
+func (s *KnowledgeBaseService) AddDocuments(ctx context.Context, collectionName string, filepath string, source string) error {
+	embedder, done, err := s.openForWrite(ctx, collectionName, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Ollama embedding function")
+		log.Printf("❌ %v\n", err)
+		return err
 	}
+	defer done()
 
-	embedding, err := ef.EmbedQuery(ctx, content)
-	if err != nil {
-		return nil, fmt.Errorf("failed to embed content")
-	}
-
-	return embedding, nil
-}
-
-func AddDocuments(ctx context.Context, collectionName string, filepath string) error {
-	// Khởi tạo ChromaDB client
-	client := ChromaClient()
-	if client == nil {
-		log.Println("❌ Failed to create ChromaDB client")
-		return fmt.Errorf("failed to create ChromaDB client")
-	}
-	log.Println("✅ ChromaDB client created successfully")
-
-	// Tạo embedding function với Ollama
-	ef, err := ollama.NewOllamaEmbeddingFunction(
-		ollama.WithBaseURL("http://127.0.0.1:11434"),
-		ollama.WithModel("nomic-embed-text"),
-	)
-	if err != nil {
-		log.Printf("❌ Error creating Ollama embedding function: %s\n", err)
-		return fmt.Errorf("failed to create Ollama embedding function")
-	}
-
-	// Lấy collection
-	collection, err := client.GetCollection(ctx, collectionName, ef)
-	if err != nil {
-		log.Fatalf("Failed to create collection: %v", err)
-		return fmt.Errorf("failed to create collection")
-	}
-
-	// Đọc file PDF
-	data, err := readPDF(filepath)
-	if err != nil {
-		log.Fatalf("Error reading PDF: %v\n", err)
-		return fmt.Errorf("failed to read PDF")
-	}
-
-	// Split content into chunks
-	chunks := splitContent(data)
-
-	for i, chunk := range chunks {
-		fmt.Printf("chunk: %v\n", chunk)
-		embedding, err := generateEmbeddings(ctx, chunk)
-		if err != nil {
-			log.Fatalf("Error generating embeddings: %v\n", err)
-			return fmt.Errorf("failed to generate embeddings")
-		}
-		fmt.Printf("embedding: %v\n", embedding)
-		metadata := map[string]interface{}{
-			"source": filepath,
-			"page":   i + 1,
-		}
-		_, err = collection.Add(
-			ctx,
-			[]*types.Embedding{embedding},
-			[]map[string]interface{}{metadata},
-			[]string{chunk},
-			[]string{fmt.Sprintf("doc_1_chunk_%d", i+1)},
-		)
-		if err != nil {
-			log.Fatalf("Error adding document: %v\n", err)
-			return fmt.Errorf("failed to add document")
-		}
-		fmt.Printf("Added document: %v\n", chunk)
+	res := s.ingestFile(ctx, collectionName, embedder, filepath, source)
+	if res.Status == IngestStatusFailed || res.Status == IngestStatusUnsupported {
+		log.Printf("❌ Error adding %s: %s %s\n", source, res.Status, res.Error)
+		return fmt.Errorf("failed to add document: %s", res.Error)
 	}
+	log.Printf("✅ %s %s (%d chunks)", source, res.Status, res.Chunks)
 	return nil
 }
-func SearchKnowledgeBase(ctx context.Context, collectionName string, query string) ([]string, error) {
-	client := ChromaClient()
-	if client == nil {
-		log.Println("❌ Failed to create ChromaDB client")
-		return nil, fmt.Errorf("failed to create ChromaDB client")
-	}
-	log.Println("✅ ChromaDB client created successfully")
-
-	ef, err := ollama.NewOllamaEmbeddingFunction(
-		ollama.WithBaseURL("http://127.0.0.1:11434"),
-		ollama.WithModel("nomic-embed-text"),
-	)
-	if err != nil {
-		log.Printf("❌ Error creating Ollama embedding function: %s\n", err)
-		return nil, fmt.Errorf("failed to create Ollama embedding function")
-	}
-	// Truy vấn ChromaDB để tìm các tài liệu liên quan
-	collection, err := client.GetCollection(ctx, collectionName, ef)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get collection: %v", err)
-	}
 
-	qr, err := collection.Query(ctx, []string{query}, 5, nil, nil, nil)
+func (s *KnowledgeBaseService) SearchKnowledgeBase(ctx context.Context, collectionName string, query string) ([]string, error) {
+	// Truy vấn vector store để tìm các tài liệu liên quan
+	matches, err := s.query(ctx, collectionName, query, 5, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error querying ChromaDB: %v", err)
+		return nil, err
 	}
 
 	// Lấy danh sách các đoạn văn bản liên quan
 	relevantTexts := make([]string, 0)
-	for _, doc := range qr.Documents {
-		relevantTexts = append(relevantTexts, doc[0])
+	if len(matches) > 0 {
+		relevantTexts = append(relevantTexts, matches[0].Document)
 	}
 
 	fmt.Printf("relevantTexts: %v\n", relevantTexts)
@@ -339,47 +323,43 @@ func SearchKnowledgeBase(ctx context.Context, collectionName string, query strin
 	return relevantTexts, nil
 }
 
-// Function to Query ChromaDB
-func QueryChromaDB(ctx context.Context, query string, collectionName string, nResults int) (*QueryResponse, error) {
-	client := ChromaClient()
-	if client == nil {
-		log.Println("❌ Failed to create ChromaDB client")
-		return nil, fmt.Errorf("failed to create ChromaDB client")
+// Function to Query the vector store
+func (s *KnowledgeBaseService) QueryChromaDB(ctx context.Context, query string, collectionName string, nResults int) (*QueryResponse, error) {
+	// Perform vector search with configurable number of results
+	matches, err := s.query(ctx, collectionName, query, nResults, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection: %w", err)
 	}
-	log.Println("✅ ChromaDB client created successfully")
 
-	ef, err := ollama.NewOllamaEmbeddingFunction(
-		ollama.WithBaseURL("http://127.0.0.1:11434"),
-		ollama.WithModel("nomic-embed-text"),
-	)
-	if err != nil {
-		log.Printf("❌ Error creating Ollama embedding function: %s\n", err)
-		return nil, fmt.Errorf("failed to create Ollama embedding function")
+	resp := &QueryResponse{
+		Documents: [][]string{{}},
+		Distances: [][]float32{{}},
+		Metadatas: [][]map[string]interface{}{{}},
+		IDs:       [][]string{{}},
+	}
+	for _, m := range matches {
+		resp.Documents[0] = append(resp.Documents[0], m.Document)
+		resp.Distances[0] = append(resp.Distances[0], m.Distance)
+		resp.Metadatas[0] = append(resp.Metadatas[0], m.Metadata)
+		resp.IDs[0] = append(resp.IDs[0], m.ID)
 	}
+	return resp, nil
+}
 
-	collection, err := client.GetCollection(ctx, collectionName, ef)
+func (s *KnowledgeBaseService) query(ctx context.Context, collectionName string, query string, nResults int, where map[string]interface{}) ([]VectorMatch, error) {
+	_, embedder, err := s.openCollection(ctx, collectionName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get collection: %v", err)
+		return nil, err
+	}
+	if nResults <= 0 {
+		nResults = 5
 	}
 
-	// Perform vector search with configurable number of results
-	results, err := collection.Query(
-		ctx,
-		[]string{query},
-		int32(nResults),
-		nil, // where
-		nil, // whereDocument
-		nil, // include
-	)
+	embedding, err := embedder.EmbedQuery(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query collection: %v", err)
+		return nil, err
 	}
-
-	return &QueryResponse{
-		Documents: results.Documents,
-		Distances: results.Distances,
-		Metadatas: results.Metadatas,
-	}, nil
+	return s.store.Query(ctx, collectionName, embedding, nResults, where)
 }
 
 type QueryResponse struct {
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"vet-tails/ai/internal/models"
+)
+
+// ClinicalImageService analyses clinical photos with the vision model.
+type ClinicalImageService struct {
+	llava *LlavaService
+}
+
+func NewClinicalImageService(llava *LlavaService) *ClinicalImageService {
+	return &ClinicalImageService{llava: llava}
+}
+
+// clinicalImageGuidance tells the model what to look for in each image type.
+var clinicalImageGuidance = map[string]string{
+	models.ClinicalImageLesion: `This is a photo of a skin lesion. Describe each lesion's
+    distribution, primary and secondary lesion type (macule, papule, plaque,
+    nodule, pustule, crust, scale, ulcer, alopecia...), margins, surface and
+    any hair loss or pigment change.`,
+	models.ClinicalImageWound: `This is a photo of a wound. Describe the wound type
+    (laceration, abrasion, puncture, bite, burn, degloving, surgical), depth,
+    edges, exposed structures, tissue in the wound bed (granulation, slough,
+    necrosis), exudate and signs of infection.`,
+	models.ClinicalImageEarCytology: `This is a microscope photo of an ear cytology
+    slide. Describe the organisms seen (cocci, rods, Malassezia yeast, mites),
+    an estimate of their number per high-power field, inflammatory cells and
+    the stain quality.`,
+	models.ClinicalImageDental: `This is a photo of the teeth and gums. Describe
+    each affected tooth or region (use the modified Triadan system where
+    possible), calculus and plaque grade, gingivitis, gingival recession,
+    fractures, resorptive lesions, masses and discoloration.`,
+}
+
+// MaxClinicalImages caps how many photos of a finding are analysed together.
+const MaxClinicalImages = 6
+
+// Analyze reads one or more photos of the same clinical finding. bodySite and
+// notes are optional context from the clinician.
+func (s *ClinicalImageService) Analyze(imageType string, images []string, bodySite string, notes string) (*models.ClinicalImageAnalysis, error) {
+	guidance, ok := clinicalImageGuidance[imageType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image type %q, expected one of %s", imageType, strings.Join(models.ClinicalImageTypes, ", "))
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images provided")
+	}
+	if len(images) > MaxClinicalImages {
+		return nil, fmt.Errorf("at most %d images can be analysed together", MaxClinicalImages)
+	}
+
+	var context strings.Builder
+	if bodySite != "" {
+		fmt.Fprintf(&context, "\n    Body site: %s", bodySite)
+	}
+	if notes != "" {
+		fmt.Fprintf(&context, "\n    Clinician notes: %s", notes)
+	}
+
+	prompt := fmt.Sprintf(`As a veterinary AI assistant, analyze the following clinical image.
+    %s
+%s
+
+    For each finding report:
+    - Location on the body or tooth
+    - Size estimate (in cm or mm; say "unable to estimate" without a scale reference)
+    - Color
+    - Morphology
+    Then list differential diagnoses ranked by likelihood (high, moderate or
+    low) with a short rationale, and diagnostics that would confirm them.
+    Rate the image quality (good, fair or poor) and your overall confidence
+    between 0 and 1. Set "disclaimer_level" to "urgent" if the image suggests
+    a condition needing prompt attention, "veterinary_review" if findings need
+    confirmation and "informational" if the image is purely descriptive.
+    Do not guess beyond what is visible.
+
+    Respond only with valid JSON matching this example:
+    {
+        "body_site": "left flank",
+        "image_quality": "good",
+        "observations": [
+            {
+                "location": "left flank",
+                "size_estimate": "2 cm diameter",
+                "color": "erythematous",
+                "morphology": "raised circular plaque with crusted margins",
+                "description": "short free-text description"
+            }
+        ],
+        "differentials": [
+            {"diagnosis": "diagnosis", "likelihood": "moderate", "rationale": "why"}
+        ],
+        "recommended_diagnostics": ["test1", "test2"],
+        "confidence": 0.6,
+        "disclaimer_level": "veterinary_review"
+    }`, guidance, context.String())
+
+	var analysis models.ClinicalImageAnalysis
+	if err := s.llava.generate(prompt, images, &analysis); err != nil {
+		return nil, fmt.Errorf("error parsing image analysis: %v", err)
+	}
+	analysis.ImageType = imageType
+	if analysis.BodySite == "" {
+		analysis.BodySite = bodySite
+	}
+	analysis.Model = s.llava.model
+	analysis.AnalyzedAt = time.Now()
+	analysis.Normalize()
+
+	return &analysis, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/amikos-tech/chroma-go/pkg/embeddings/ollama"
+	"github.com/amikos-tech/chroma-go/types"
+)
+
+// Collection metadata keys recording which embedder populated a collection.
+const (
+	MetadataEmbeddingModel     = "embedding_model"
+	MetadataEmbeddingDimension = "embedding_dimension"
+)
+
+// Collections created before the embedder was recorded in metadata were all
+// populated with this model.
+const (
+	LegacyEmbeddingModel     = "nomic-embed-text"
+	LegacyEmbeddingDimension = 768
+)
+
+var ErrEmbedderMismatch = errors.New("collection was embedded with a different model")
+
+// Embedder turns text into vectors. Implementations must always return
+// vectors of Dimension() length for a given Model().
+type Embedder interface {
+	Model() string
+	Dimension() int
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+type OllamaEmbedder struct {
+	model     string
+	dimension int
+	ef        *ollama.OllamaEmbeddingFunction
+}
+
+func NewOllamaEmbedder(baseURL string, model string, dimension int) (*OllamaEmbedder, error) {
+	ef, err := ollama.NewOllamaEmbeddingFunction(
+		ollama.WithBaseURL(baseURL),
+		ollama.WithModel(model),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Ollama embedding function: %v", err)
+	}
+	return &OllamaEmbedder{
+		model:     model,
+		dimension: dimension,
+		ef:        ef,
+	}, nil
+}
+
+func (e *OllamaEmbedder) Model() string  { return e.model }
+func (e *OllamaEmbedder) Dimension() int { return e.dimension }
+
+func (e *OllamaEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	embeddings, err := e.ef.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed documents: %v", err)
+	}
+	vectors := make([][]float32, len(embeddings))
+	for i, emb := range embeddings {
+		if vectors[i], err = e.checkDimension(emb); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+func (e *OllamaEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	emb, err := e.ef.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed content: %v", err)
+	}
+	return e.checkDimension(emb)
+}
+
+func (e *OllamaEmbedder) checkDimension(emb *types.Embedding) ([]float32, error) {
+	if emb == nil || emb.GetFloat32() == nil {
+		return nil, fmt.Errorf("embedding model %s returned no vector", e.model)
+	}
+	v := *emb.GetFloat32()
+	if e.dimension > 0 && len(v) != e.dimension {
+		return nil, fmt.Errorf("embedding model %s returned %d dimensions, expected %d", e.model, len(v), e.dimension)
+	}
+	return v, nil
+}
+
+// chromaEmbeddingFunction adapts an Embedder to chroma-go's EmbeddingFunction.
+type chromaEmbeddingFunction struct {
+	embedder Embedder
+}
+
+var _ types.EmbeddingFunction = chromaEmbeddingFunction{}
+
+func (f chromaEmbeddingFunction) EmbedDocuments(ctx context.Context, texts []string) ([]*types.Embedding, error) {
+	vectors, err := f.embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewEmbeddingsFromFloat32(vectors), nil
+}
+
+func (f chromaEmbeddingFunction) EmbedQuery(ctx context.Context, text string) (*types.Embedding, error) {
+	vector, err := f.embedder.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewEmbeddingFromFloat32(vector), nil
+}
+
+func (f chromaEmbeddingFunction) EmbedRecords(ctx context.Context, records []*types.Record, force bool) error {
+	return types.EmbedRecordsDefaultImpl(f, ctx, records, force)
+}
+
+// embedderMetadata returns the collection metadata entries for an embedder.
+func embedderMetadata(e Embedder) map[string]interface{} {
+	return map[string]interface{}{
+		MetadataEmbeddingModel:     e.Model(),
+		MetadataEmbeddingDimension: e.Dimension(),
+	}
+}
+
+// collectionEmbedder reads the embedding model and dimension recorded in
+// collection metadata, falling back to the legacy model.
+func collectionEmbedder(metadata map[string]interface{}) (string, int) {
+	model, _ := metadata[MetadataEmbeddingModel].(string)
+	if model == "" {
+		return LegacyEmbeddingModel, LegacyEmbeddingDimension
+	}
+
+	dimension := 0
+	switch v := metadata[MetadataEmbeddingDimension].(type) {
+	case int:
+		dimension = v
+	case int32:
+		dimension = int(v)
+	case int64:
+		dimension = int(v)
+	case float32:
+		dimension = int(v)
+	case float64:
+		dimension = int(v)
+	case string:
+		dimension, _ = strconv.Atoi(v)
+	}
+	return model, dimension
+}
+
+// checkEmbedder refuses to use an embedder that does not match the one the
+// collection was populated with.
+func checkEmbedder(collectionName string, metadata map[string]interface{}, e Embedder) error {
+	model, dimension := collectionEmbedder(metadata)
+	if model != e.Model() || (dimension > 0 && e.Dimension() > 0 && dimension != e.Dimension()) {
+		return fmt.Errorf("%w: %s uses %s (%d dimensions), configured embedder is %s (%d dimensions)",
+			ErrEmbedderMismatch, collectionName, model, dimension, e.Model(), e.Dimension())
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EmbeddingCache is a persistent, size-bounded LRU cache of embedding vectors
+// keyed by embedding model and a SHA-256 of the embedded text. Each vector is
+// stored as a little-endian float32 file under dir/<model>/<key[:2]>/<key>.
+type EmbeddingCache struct {
+	dir        string
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type cacheEntry struct {
+	key  string
+	path string
+}
+
+type EmbeddingCacheStats struct {
+	Entries    int     `json:"entries"`
+	MaxEntries int     `json:"max_entries"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	Evictions  uint64  `json:"evictions"`
+	HitRate    float64 `json:"hit_rate"`
+}
+
+// NewEmbeddingCache opens (or creates) a cache directory and indexes the
+// vectors already stored there, most recently used last.
+func NewEmbeddingCache(dir string, maxEntries int) (*EmbeddingCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating embedding cache directory: %v", err)
+	}
+
+	c := &EmbeddingCache{
+		dir:        dir,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+
+	type stored struct {
+		entry   cacheEntry
+		modTime time.Time
+	}
+	var found []stored
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".vec" {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		model := filepath.Dir(filepath.Dir(rel))
+		hash := filepath.Base(path[:len(path)-len(".vec")])
+		found = append(found, stored{cacheEntry{key: model + "/" + hash, path: path}, info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error indexing embedding cache: %v", err)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime.Before(found[j].modTime) })
+	for _, f := range found {
+		c.entries[f.entry.key] = c.lru.PushFront(&f.entry)
+	}
+
+	c.mu.Lock()
+	c.evictLocked()
+	c.mu.Unlock()
+
+	log.Printf("✅ Embedding cache loaded %d vectors from %s", c.lru.Len(), dir)
+	return c, nil
+}
+
+func (c *EmbeddingCache) entryFor(model, text string) cacheEntry {
+	sum := sha256.Sum256([]byte(text))
+	hash := hex.EncodeToString(sum[:])
+	dirName := sanitizeModelName(model)
+	return cacheEntry{
+		key:  dirName + "/" + hash,
+		path: filepath.Join(c.dir, dirName, hash[:2], hash+".vec"),
+	}
+}
+
+// Get returns the cached vector for text under model, if any.
+func (c *EmbeddingCache) Get(model, text string) ([]float32, bool) {
+	e := c.entryFor(model, text)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[e.key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	vector, err := readVector(e.path)
+	if err != nil {
+		// The file vanished or is corrupt; forget it.
+		c.lru.Remove(el)
+		delete(c.entries, e.key)
+		c.misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	now := time.Now()
+	os.Chtimes(e.path, now, now)
+	c.hits++
+	return vector, true
+}
+
+// Put stores a vector and evicts the least recently used entries beyond the
+// configured maximum.
+func (c *EmbeddingCache) Put(model, text string, vector []float32) error {
+	e := c.entryFor(model, text)
+	if err := writeVector(e.path, vector); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[e.key]; ok {
+		c.lru.MoveToFront(el)
+		return nil
+	}
+	c.entries[e.key] = c.lru.PushFront(&e)
+	c.evictLocked()
+	return nil
+}
+
+func (c *EmbeddingCache) evictLocked() {
+	if c.maxEntries <= 0 {
+		return
+	}
+	for c.lru.Len() > c.maxEntries {
+		el := c.lru.Back()
+		e := el.Value.(*cacheEntry)
+		os.Remove(e.path)
+		c.lru.Remove(el)
+		delete(c.entries, e.key)
+		c.evictions++
+	}
+}
+
+func (c *EmbeddingCache) Stats() EmbeddingCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := EmbeddingCacheStats{
+		Entries:    c.lru.Len(),
+		MaxEntries: c.maxEntries,
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Evictions:  c.evictions,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+func sanitizeModelName(model string) string {
+	out := []byte(model)
+	for i, b := range out {
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', b == '-', b == '_', b == '.':
+		default:
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
+
+func readVector(path string) ([]float32, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%4 != 0 {
+		return nil, fmt.Errorf("corrupt cached vector %s", path)
+	}
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector, nil
+}
+
+func writeVector(path string, vector []float32) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating cache directory: %v", err)
+	}
+	data := make([]byte, len(vector)*4)
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".vec-*")
+	if err != nil {
+		return fmt.Errorf("error writing cached vector: %v", err)
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing cached vector: %v", err)
+	}
+	return nil
+}
+
+// CachedEmbedder serves embeddings from an EmbeddingCache and only calls the
+// wrapped embedder for texts it has not seen under the same model.
+type CachedEmbedder struct {
+	Embedder
+	cache *EmbeddingCache
+}
+
+func NewCachedEmbedder(embedder Embedder, cache *EmbeddingCache) *CachedEmbedder {
+	return &CachedEmbedder{Embedder: embedder, cache: cache}
+}
+
+// NewEmbedder builds an Ollama embedder, wrapped with cache when one is given.
+func NewEmbedder(baseURL string, model string, dimension int, cache *EmbeddingCache) (Embedder, error) {
+	embedder, err := NewOllamaEmbedder(baseURL, model, dimension)
+	if err != nil {
+		return nil, err
+	}
+	if cache == nil {
+		return embedder, nil
+	}
+	return NewCachedEmbedder(embedder, cache), nil
+}
+
+func (e *CachedEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	var missing []string
+	var missingIdx []int
+	for i, text := range texts {
+		if v, ok := e.cache.Get(e.Model(), text); ok && (e.Dimension() == 0 || len(v) == e.Dimension()) {
+			vectors[i] = v
+			continue
+		}
+		missing = append(missing, text)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := e.Embedder.EmbedDocuments(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embedding model %s returned %d vectors for %d texts", e.Model(), len(embedded), len(missing))
+	}
+	for j, v := range embedded {
+		vectors[missingIdx[j]] = v
+		if err := e.cache.Put(e.Model(), missing[j], v); err != nil {
+			log.Printf("⚠️ Failed to cache embedding: %v\n", err)
+		}
+	}
+	return vectors, nil
+}
+
+func (e *CachedEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	if v, ok := e.cache.Get(e.Model(), text); ok && (e.Dimension() == 0 || len(v) == e.Dimension()) {
+		return v, nil
+	}
+	v, err := e.Embedder.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.cache.Put(e.Model(), text, v); err != nil {
+		log.Printf("⚠️ Failed to cache embedding: %v\n", err)
+	}
+	return v, nil
+}
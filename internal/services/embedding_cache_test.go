@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// countingEmbedder embeds each text as its length and counts the texts it
+// was asked to embed.
+type countingEmbedder struct {
+	model    string
+	embedded int
+}
+
+func (e *countingEmbedder) Model() string  { return e.model }
+func (e *countingEmbedder) Dimension() int { return 2 }
+
+func (e *countingEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	e.embedded += len(texts)
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = []float32{float32(len(t)), 1}
+	}
+	return out, nil
+}
+
+func (e *countingEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	e.embedded++
+	return []float32{float32(len(text)), 1}, nil
+}
+
+func newTestEmbeddingCache(t *testing.T, dir string, maxEntries int) *EmbeddingCache {
+	t.Helper()
+	cache, err := NewEmbeddingCache(dir, maxEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func TestCachedEmbedderCountsHitsAndMisses(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingEmbedder{model: "nomic"}
+	embedder := NewCachedEmbedder(inner, newTestEmbeddingCache(t, t.TempDir(), 0))
+
+	if _, err := embedder.EmbedDocuments(ctx, []string{"a", "bb"}); err != nil {
+		t.Fatal(err)
+	}
+	vectors, err := embedder.EmbedDocuments(ctx, []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := embedder.EmbedQuery(ctx, "bb"); err != nil {
+		t.Fatal(err)
+	}
+
+	if inner.embedded != 3 {
+		t.Errorf("embedded %d texts, want 3", inner.embedded)
+	}
+	if len(vectors) != 3 || vectors[1][0] != 2 || vectors[2][0] != 3 {
+		t.Errorf("vectors = %v, want the embeddings of a, bb, ccc", vectors)
+	}
+	stats := embedder.cache.Stats()
+	if stats.Hits != 3 || stats.Misses != 3 || stats.Entries != 3 {
+		t.Errorf("stats = %+v, want 3 hits, 3 misses, 3 entries", stats)
+	}
+	if stats.HitRate != 0.5 {
+		t.Errorf("hit rate = %v, want 0.5", stats.HitRate)
+	}
+}
+
+func TestEmbeddingCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newTestEmbeddingCache(t, t.TempDir(), 2)
+	for _, text := range []string{"a", "b"} {
+		if err := cache.Put("nomic", text, []float32{1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reading a makes b the least recently used entry.
+	if _, ok := cache.Get("nomic", "a"); !ok {
+		t.Fatal("a is missing before the cache is full")
+	}
+	if err := cache.Put("nomic", "c", []float32{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for text, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := cache.Get("nomic", text); ok != want {
+			t.Errorf("Get(%s) found = %v, want %v", text, ok, want)
+		}
+	}
+	if _, err := os.Stat(cache.entryFor("nomic", "b").path); !os.IsNotExist(err) {
+		t.Errorf("evicted vector file still exists: %v", err)
+	}
+	if stats := cache.Stats(); stats.Entries != 2 || stats.Evictions != 1 {
+		t.Errorf("stats = %+v, want 2 entries, 1 eviction", stats)
+	}
+}
+
+func TestEmbeddingCacheSeparatesModels(t *testing.T) {
+	cache := newTestEmbeddingCache(t, t.TempDir(), 0)
+	if err := cache.Put("nomic-embed-text", "fever", []float32{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("mxbai-embed-large", "fever"); ok {
+		t.Error("vector cached under one model was served for another")
+	}
+	if err := cache.Put("mxbai-embed-large", "fever", []float32{3, 4, 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		model string
+		want  []float32
+	}{
+		{"nomic-embed-text", []float32{1, 2}},
+		{"mxbai-embed-large", []float32{3, 4, 5}},
+	}
+	for _, tt := range tests {
+		got, ok := cache.Get(tt.model, "fever")
+		if !ok || len(got) != len(tt.want) || got[0] != tt.want[0] {
+			t.Errorf("Get(%s) = %v, %v, want %v", tt.model, got, ok, tt.want)
+		}
+	}
+}
+
+func TestEmbeddingCacheReloadsFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	cache := newTestEmbeddingCache(t, dir, 0)
+	base := time.Now().Add(-time.Hour)
+	for i, text := range []string{"old", "mid", "new"} {
+		if err := cache.Put("nomic", text, []float32{float32(i), 0.5}); err != nil {
+			t.Fatal(err)
+		}
+		// Space the files out so the reload order does not depend on the
+		// filesystem's timestamp resolution.
+		at := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(cache.entryFor("nomic", text).path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded := newTestEmbeddingCache(t, dir, 0)
+	if got, ok := reloaded.Get("nomic", "mid"); !ok || len(got) != 2 || got[0] != 1 || got[1] != 0.5 {
+		t.Errorf("Get(mid) after reload = %v, %v, want [1 0.5]", got, ok)
+	}
+	if stats := reloaded.Stats(); stats.Entries != 3 {
+		t.Errorf("reloaded %d entries, want 3", stats.Entries)
+	}
+
+	// Reading mid touched its file, so reopening with a smaller cap drops
+	// old, the least recently used vector.
+	capped := newTestEmbeddingCache(t, dir, 2)
+	for text, want := range map[string]bool{"old": false, "mid": true, "new": true} {
+		if _, ok := capped.Get("nomic", text); ok != want {
+			t.Errorf("Get(%s) after capped reload found = %v, want %v", text, ok, want)
+		}
+	}
+}
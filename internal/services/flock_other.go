@@ -0,0 +1,12 @@
+//go:build !unix
+
+package services
+
+import "os"
+
+// Advisory file locks are only implemented on Unix. Elsewhere only one
+// process may use a LocalStore directory at a time.
+
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
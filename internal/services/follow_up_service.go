@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"vet-tails/ai/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskNotFound      = errors.New("follow-up task not found")
+	ErrInvalidTransition = errors.New("invalid task status change")
+)
+
+// FollowUpService turns flagged activity logs into follow-up tasks for the
+// patient's clinic and tracks them through to resolution.
+type FollowUpService struct {
+	db   *gorm.DB
+	soap *SOAPService
+}
+
+func NewFollowUpService(db *gorm.DB, soap *SOAPService) *FollowUpService {
+	return &FollowUpService{db: db, soap: soap}
+}
+
+// TaskFilter selects tasks for the queue. Zero values match everything.
+type TaskFilter struct {
+	ClinicID  string
+	PatientID uint
+	Status    []string
+	Severity  []string
+	Limit     int
+	Offset    int
+}
+
+// RecordActivity stores an activity log and, when it is flagged, the
+// follow-up task for its concerns in one transaction, so a failure stores
+// neither and the report can be resubmitted. Triage runs before the
+// transaction; if it fails the task is still created at medium severity so
+// the concern is not lost. The returned task is nil for unflagged logs.
+func (s *FollowUpService) RecordActivity(activity *models.ActivityLog, patient *models.Patient) (*models.FollowUpTask, error) {
+	if !activity.Flagged {
+		if err := s.db.Create(activity).Error; err != nil {
+			return nil, fmt.Errorf("error saving activity log: %v", err)
+		}
+		return nil, nil
+	}
+
+	now := time.Now()
+	task := &models.FollowUpTask{
+		PatientID: patient.ID,
+		ClinicID:  patient.ClinicID,
+		Title:     followUpTitle(activity, patient),
+		Concerns:  activity.Concerns,
+		Status:    models.TaskStatusOpen,
+	}
+
+	triage, err := s.soap.TriageConcerns(activity, describePatient(patient))
+	if err != nil {
+		log.Printf("⚠️ Triage failed for patient %d: %v\n", patient.ID, err)
+		triage = &models.ConcernTriage{
+			Severity:  models.SeverityMedium,
+			Reasoning: "Automatic triage was unavailable; review manually.",
+		}
+	}
+	task.SetSeverity(triage.Severity, now)
+	task.TriageReasoning = triage.Reasoning
+	task.RecommendedAction = triage.RecommendedAction
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(activity).Error; err != nil {
+			return err
+		}
+		task.ActivityLogID = activity.ID
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.FollowUpTaskEvent{
+			TaskID:   task.ID,
+			Action:   models.TaskActionCreated,
+			Actor:    "system",
+			ToStatus: task.Status,
+			Note:     fmt.Sprintf("Triaged as %s", task.Severity),
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving activity log and follow-up task: %v", err)
+	}
+	return task, nil
+}
+
+// ListTasks returns the matching tasks, most pressing first, with the total
+// number of matches.
+func (s *FollowUpService) ListTasks(filter TaskFilter) ([]models.FollowUpTask, int64, error) {
+	query := s.db.Model(&models.FollowUpTask{})
+	if filter.ClinicID != "" {
+		query = query.Where("clinic_id = ?", filter.ClinicID)
+	}
+	if filter.PatientID != 0 {
+		query = query.Where("patient_id = ?", filter.PatientID)
+	}
+	if len(filter.Status) > 0 {
+		query = query.Where("status IN ?", filter.Status)
+	}
+	if len(filter.Severity) > 0 {
+		query = query.Where("severity IN ?", filter.Severity)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("error counting tasks: %v", err)
+	}
+	var tasks []models.FollowUpTask
+	err := query.Order("severity_rank DESC, due_at ASC, id ASC").Limit(filter.Limit).Offset(filter.Offset).Find(&tasks).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing tasks: %v", err)
+	}
+	return tasks, total, nil
+}
+
+// GetTask returns a task with its audit trail.
+func (s *FollowUpService) GetTask(id uint) (*models.FollowUpTask, error) {
+	var task models.FollowUpTask
+	err := s.db.Preload("Events", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC, id ASC")
+	}).First(&task, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("error loading task: %v", err)
+	}
+	return &task, nil
+}
+
+// Acknowledge records that actor has taken ownership of an open task.
+func (s *FollowUpService) Acknowledge(id uint, actor string, note string) (*models.FollowUpTask, error) {
+	return s.transition(id, actor, note, models.TaskActionAcknowledged, func(task *models.FollowUpTask, now time.Time) error {
+		if task.Status != models.TaskStatusOpen {
+			return fmt.Errorf("%w: task is %s", ErrInvalidTransition, task.Status)
+		}
+		task.Status = models.TaskStatusAcknowledged
+		task.AcknowledgedBy = actor
+		task.AcknowledgedAt = &now
+		return nil
+	})
+}
+
+// Resolve closes a task. Open tasks are acknowledged by the same actor on the
+// way.
+func (s *FollowUpService) Resolve(id uint, actor string, resolution string) (*models.FollowUpTask, error) {
+	return s.transition(id, actor, resolution, models.TaskActionResolved, func(task *models.FollowUpTask, now time.Time) error {
+		if task.Status == models.TaskStatusResolved {
+			return fmt.Errorf("%w: task is already resolved", ErrInvalidTransition)
+		}
+		if task.AcknowledgedAt == nil {
+			task.AcknowledgedBy = actor
+			task.AcknowledgedAt = &now
+		}
+		task.Status = models.TaskStatusResolved
+		task.ResolvedBy = actor
+		task.ResolvedAt = &now
+		task.Resolution = resolution
+		return nil
+	})
+}
+
+// transition applies a status change and records it in the audit trail in a
+// single transaction.
+func (s *FollowUpService) transition(id uint, actor string, note string, action string, apply func(task *models.FollowUpTask, now time.Time) error) (*models.FollowUpTask, error) {
+	var task models.FollowUpTask
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&task, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return err
+		}
+		from := task.Status
+		if err := apply(&task, time.Now()); err != nil {
+			return err
+		}
+		// Only update the row if nobody changed the status in the meantime.
+		res := tx.Model(&task).Where("status = ?", from).
+			Select("status", "acknowledged_by", "acknowledged_at", "resolved_by", "resolved_at", "resolution", "updated_at").
+			Updates(&task)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w: task was modified concurrently", ErrInvalidTransition)
+		}
+		return tx.Create(&models.FollowUpTaskEvent{
+			TaskID:     task.ID,
+			Action:     action,
+			Actor:      actor,
+			FromStatus: from,
+			ToStatus:   task.Status,
+			Note:       note,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrInvalidTransition) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating task: %v", err)
+	}
+	return s.GetTask(task.ID)
+}
+
+func followUpTitle(activity *models.ActivityLog, patient *models.Patient) string {
+	concern := "follow-up requested"
+	if len(activity.Concerns) > 0 {
+		concern = activity.Concerns[0]
+	}
+	title := fmt.Sprintf("%s: %s", patient.Name, concern)
+	if extra := len(activity.Concerns) - 1; extra > 0 {
+		title += fmt.Sprintf(" (+%d more)", extra)
+	}
+	return title
+}
+
+// describePatient gives prompts a one-line description of the animal.
+func describePatient(patient *models.Patient) string {
+	parts := []string{patient.Name}
+	for _, p := range []string{patient.Species, patient.Breed} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if !patient.DateOfBirth.IsZero() {
+		parts = append(parts, "born "+patient.DateOfBirth.Format("2006-01-02"))
+	}
+	return strings.Join(parts, ", ")
+}
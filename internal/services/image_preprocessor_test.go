@@ -0,0 +1,52 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func TestPrepareImageFormats(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatal(err)
+	}
+	webpData, err := os.ReadFile("testdata/gopher.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"png", pngData.Bytes(), nil},
+		{"webp", webpData, nil},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), ErrUnsupportedImage},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), ErrUnsupportedImage},
+		{"tiff", []byte("II*\x00\x08\x00\x00\x00"), ErrUnsupportedImage},
+		{"unknown", []byte("not an image"), ErrUnsupportedImage},
+		{"truncated webp", webpData[:20], ErrInvalidImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prepared, err := PrepareImage(tt.data, 16)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if prepared.Format != tt.name {
+				t.Errorf("format = %s, want %s", prepared.Format, tt.name)
+			}
+			if prepared.Width > 16 || prepared.Height > 16 || prepared.Base64 == "" {
+				t.Errorf("prepared = %+v, want a JPEG within 16x16", prepared)
+			}
+		})
+	}
+}
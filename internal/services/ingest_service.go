@@ -0,0 +1,369 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Per-file ingestion outcomes reported in IngestFileResult.Status.
+const (
+	IngestStatusAdded       = "added"
+	IngestStatusUpdated     = "updated"
+	IngestStatusSkipped     = "skipped"
+	IngestStatusUnsupported = "unsupported"
+	IngestStatusFailed      = "failed"
+)
+
+// MaxIngestFileSize caps how much of a single archive entry is extracted.
+const MaxIngestFileSize = 100 << 20
+
+type IngestFileResult struct {
+	Source string `json:"source"`
+	Status string `json:"status"`
+	Hash   string `json:"hash,omitempty"`
+	Chunks int    `json:"chunks"`
+	Error  string `json:"error,omitempty"`
+}
+
+type IngestReport struct {
+	Collection  string             `json:"collection"`
+	Files       []IngestFileResult `json:"files"`
+	Added       int                `json:"added"`
+	Updated     int                `json:"updated"`
+	Skipped     int                `json:"skipped"`
+	Unsupported int                `json:"unsupported"`
+	Failed      int                `json:"failed"`
+	Chunks      int                `json:"chunks"`
+	StartedAt   time.Time          `json:"started_at"`
+	FinishedAt  time.Time          `json:"finished_at"`
+}
+
+func (r *IngestReport) record(res IngestFileResult) {
+	r.Files = append(r.Files, res)
+	r.Chunks += res.Chunks
+	switch res.Status {
+	case IngestStatusAdded:
+		r.Added++
+	case IngestStatusUpdated:
+		r.Updated++
+	case IngestStatusSkipped:
+		r.Skipped++
+	case IngestStatusUnsupported:
+		r.Unsupported++
+	case IngestStatusFailed:
+		r.Failed++
+	}
+}
+
+// IsSupportedDocument reports whether the file extension has a reader.
+func IsSupportedDocument(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf", ".txt", ".md":
+		return true
+	}
+	return false
+}
+
+func readDocument(filePath string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".pdf":
+		return readPDF(filePath)
+	case ".txt", ".md":
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf("error reading file: %v", err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("unsupported file type: %s", filepath.Ext(filePath))
+}
+
+func hashFile(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ingestFile chunks, embeds and stores one file under the given source name.
+// Chunks already stored for the source are left alone when the file hash is
+// unchanged and replaced otherwise. New chunks are written before the stale
+// ones are removed, so a failed update keeps the previous version.
+func (s *KnowledgeBaseService) ingestFile(ctx context.Context, collection string, embedder Embedder, filePath string, source string) IngestFileResult {
+	res := IngestFileResult{Source: source}
+	fail := func(err error) IngestFileResult {
+		res.Status = IngestStatusFailed
+		res.Error = err.Error()
+		return res
+	}
+
+	if !IsSupportedDocument(filePath) {
+		res.Status = IngestStatusUnsupported
+		return res
+	}
+
+	hash, err := hashFile(filePath)
+	if err != nil {
+		return fail(fmt.Errorf("error hashing file: %v", err))
+	}
+	res.Hash = hash
+
+	existing, err := s.store.Get(ctx, collection, map[string]interface{}{"source": source}, 0, 0)
+	if err != nil {
+		return fail(fmt.Errorf("error looking up existing chunks: %v", err))
+	}
+	var current, stale []string
+	for _, r := range existing {
+		if r.Metadata["file_hash"] == hash {
+			current = append(current, r.ID)
+		} else {
+			stale = append(stale, r.ID)
+		}
+	}
+	// A complete copy of this version is already stored; only leftovers of
+	// older versions, if any, need removing.
+	if len(current) > 0 && len(current) == storedChunkCount(existing, hash) {
+		if len(stale) > 0 {
+			if err := s.store.Delete(ctx, collection, stale, nil); err != nil {
+				return fail(fmt.Errorf("error removing stale chunks: %v", err))
+			}
+		}
+		res.Status = IngestStatusSkipped
+		return res
+	}
+	status := IngestStatusAdded
+	if len(stale) > 0 {
+		status = IngestStatusUpdated
+	}
+
+	data, err := readDocument(filePath)
+	if err != nil {
+		return fail(err)
+	}
+	chunks := splitContent(data)
+	if len(chunks) == 0 {
+		return fail(fmt.Errorf("no extractable text"))
+	}
+
+	vectors, err := embedder.EmbedDocuments(ctx, chunks)
+	if err != nil {
+		return fail(err)
+	}
+	prefix := chunkIDPrefix(source, hash)
+	records := make([]VectorRecord, len(chunks))
+	for i, chunk := range chunks {
+		records[i] = VectorRecord{
+			ID:        fmt.Sprintf("%s_chunk_%d", prefix, i+1),
+			Document:  chunk,
+			Embedding: vectors[i],
+			Metadata: map[string]interface{}{
+				"source":      source,
+				"file_hash":   hash,
+				"chunk":       i + 1,
+				"chunk_count": len(chunks),
+			},
+		}
+	}
+
+	// Chunks of an incomplete earlier attempt at this version may share the
+	// new IDs; they are replaced. Any other chunks of this version, such as
+	// ones stored under older IDs, go with the stale ones.
+	newIDs := make(map[string]bool, len(records))
+	for _, r := range records {
+		newIDs[r.ID] = true
+	}
+	var colliding []string
+	for _, id := range current {
+		if newIDs[id] {
+			colliding = append(colliding, id)
+		} else {
+			stale = append(stale, id)
+		}
+	}
+	if len(colliding) > 0 {
+		if err := s.store.Delete(ctx, collection, colliding, nil); err != nil {
+			return fail(fmt.Errorf("error removing incomplete chunks: %v", err))
+		}
+	}
+	if err := s.store.Add(ctx, collection, records); err != nil {
+		return fail(err)
+	}
+	if len(stale) > 0 {
+		if err := s.store.Delete(ctx, collection, stale, nil); err != nil {
+			return fail(fmt.Errorf("error removing stale chunks: %v", err))
+		}
+	}
+
+	res.Status = status
+	res.Chunks = len(chunks)
+	return res
+}
+
+// chunkIDPrefix identifies one version of one source. The source is part of
+// the ID so the same file stored under two sources does not collide.
+func chunkIDPrefix(source string, hash string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:8]) + "_" + hash[:16]
+}
+
+// storedChunkCount returns how many chunks the stored version with this hash
+// was split into, or -1 when that is not recorded.
+func storedChunkCount(records []VectorRecord, hash string) int {
+	for _, r := range records {
+		if r.Metadata["file_hash"] != hash {
+			continue
+		}
+		switch n := r.Metadata["chunk_count"].(type) {
+		case int:
+			return n
+		case float64:
+			return int(n)
+		}
+	}
+	return -1
+}
+
+// IngestDirectory walks dir recursively and ingests every supported file into
+// the collection, creating it if needed. Sources are recorded relative to dir.
+func (s *KnowledgeBaseService) IngestDirectory(ctx context.Context, collectionName string, dir string) (*IngestReport, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	embedder, done, err := s.openForWrite(ctx, collectionName, true)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	var paths []string
+	err = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking directory: %v", err)
+	}
+	sort.Strings(paths)
+
+	report := &IngestReport{Collection: collectionName, StartedAt: time.Now()}
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		source, err := filepath.Rel(dir, p)
+		if err != nil {
+			source = p
+		}
+		res := s.ingestFile(ctx, collectionName, embedder, p, filepath.ToSlash(source))
+		log.Printf("📄 %s: %s", res.Source, res.Status)
+		report.record(res)
+	}
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// IngestZip ingests every supported entry of a ZIP archive into the
+// collection, creating it if needed. Entries are extracted one at a time into
+// temporary files; entry names are only used as source labels, never as
+// filesystem paths.
+func (s *KnowledgeBaseService) IngestZip(ctx context.Context, collectionName string, zipPath string) (*IngestReport, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ZIP archive: %v", err)
+	}
+	defer zr.Close()
+
+	embedder, done, err := s.openForWrite(ctx, collectionName, true)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	files := make([]*zip.File, 0, len(zr.File))
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	report := &IngestReport{Collection: collectionName, StartedAt: time.Now()}
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		source := path.Clean("/" + filepath.ToSlash(f.Name))[1:]
+		if !IsSupportedDocument(source) {
+			report.record(IngestFileResult{Source: source, Status: IngestStatusUnsupported})
+			continue
+		}
+
+		tmpPath, err := extractZipEntry(f)
+		if err != nil {
+			report.record(IngestFileResult{Source: source, Status: IngestStatusFailed, Error: err.Error()})
+			continue
+		}
+		res := s.ingestFile(ctx, collectionName, embedder, tmpPath, source)
+		os.Remove(tmpPath)
+		log.Printf("📄 %s: %s", res.Source, res.Status)
+		report.record(res)
+	}
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func extractZipEntry(f *zip.File) (string, error) {
+	if f.UncompressedSize64 > MaxIngestFileSize {
+		return "", fmt.Errorf("file exceeds %d bytes", MaxIngestFileSize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("error opening archive entry: %v", err)
+	}
+	defer rc.Close()
+
+	tmp, err := os.CreateTemp("", "ingest-*"+strings.ToLower(filepath.Ext(f.Name)))
+	if err != nil {
+		return "", fmt.Errorf("error creating temp file: %v", err)
+	}
+	defer tmp.Close()
+
+	n, err := io.Copy(tmp, io.LimitReader(rc, MaxIngestFileSize+1))
+	if err == nil && n > MaxIngestFileSize {
+		err = fmt.Errorf("file exceeds %d bytes", MaxIngestFileSize)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type stubEmbedder struct{ err error }
+
+func (e *stubEmbedder) Model() string  { return "stub" }
+func (e *stubEmbedder) Dimension() int { return 2 }
+
+func (e *stubEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = []float32{float32(len(t)), 1}
+	}
+	return out, nil
+}
+
+func (e *stubEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text)), 1}, nil
+}
+
+func newTestKnowledgeBase(t *testing.T) (*KnowledgeBaseService, *stubEmbedder) {
+	t.Helper()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedder := &stubEmbedder{}
+	kb := NewKnowledgeBaseService(store, embedder, nil)
+	if _, err := kb.CreateCollection(context.Background(), "docs"); err != nil {
+		t.Fatal(err)
+	}
+	return kb, embedder
+}
+
+func writeDoc(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sourceDocuments(t *testing.T, kb *KnowledgeBaseService, source string) []string {
+	t.Helper()
+	records, err := kb.store.Get(context.Background(), "docs", map[string]interface{}{"source": source}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []string
+	for _, r := range records {
+		docs = append(docs, r.Document)
+	}
+	return docs
+}
+
+func TestIngestFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	t.Run("unchanged file is skipped", func(t *testing.T) {
+		kb, embedder := newTestKnowledgeBase(t)
+		path := writeDoc(t, dir, "a.txt", "Meloxicam is an NSAID.")
+		if res := kb.ingestFile(ctx, "docs", embedder, path, "a.txt"); res.Status != IngestStatusAdded {
+			t.Fatalf("first ingest = %+v, want added", res)
+		}
+		if res := kb.ingestFile(ctx, "docs", embedder, path, "a.txt"); res.Status != IngestStatusSkipped {
+			t.Fatalf("second ingest = %+v, want skipped", res)
+		}
+		if docs := sourceDocuments(t, kb, "a.txt"); len(docs) != 1 {
+			t.Errorf("stored %d chunks, want 1", len(docs))
+		}
+	})
+
+	t.Run("changed file replaces old chunks", func(t *testing.T) {
+		kb, embedder := newTestKnowledgeBase(t)
+		path := writeDoc(t, dir, "b.txt", "Old guidance.")
+		kb.ingestFile(ctx, "docs", embedder, path, "b.txt")
+		writeDoc(t, dir, "b.txt", "New guidance.")
+		if res := kb.ingestFile(ctx, "docs", embedder, path, "b.txt"); res.Status != IngestStatusUpdated {
+			t.Fatalf("update = %+v, want updated", res)
+		}
+		docs := sourceDocuments(t, kb, "b.txt")
+		if len(docs) != 1 || docs[0] != "New guidance." {
+			t.Errorf("stored %q, want only the new version", docs)
+		}
+	})
+
+	t.Run("failed update keeps old chunks", func(t *testing.T) {
+		kb, embedder := newTestKnowledgeBase(t)
+		path := writeDoc(t, dir, "c.txt", "Old guidance.")
+		kb.ingestFile(ctx, "docs", embedder, path, "c.txt")
+		writeDoc(t, dir, "c.txt", "New guidance.")
+		embedder.err = errors.New("embedding service unavailable")
+		if res := kb.ingestFile(ctx, "docs", embedder, path, "c.txt"); res.Status != IngestStatusFailed {
+			t.Fatalf("update = %+v, want failed", res)
+		}
+		docs := sourceDocuments(t, kb, "c.txt")
+		if len(docs) != 1 || docs[0] != "Old guidance." {
+			t.Errorf("stored %q, want the old version", docs)
+		}
+	})
+
+	t.Run("same file under two sources", func(t *testing.T) {
+		kb, embedder := newTestKnowledgeBase(t)
+		path := writeDoc(t, dir, "d.txt", "Shared guidance.")
+		for _, source := range []string{"one/d.txt", "two/d.txt"} {
+			if res := kb.ingestFile(ctx, "docs", embedder, path, source); res.Status != IngestStatusAdded {
+				t.Fatalf("ingest %s = %+v, want added", source, res)
+			}
+		}
+		for _, source := range []string{"one/d.txt", "two/d.txt"} {
+			if docs := sourceDocuments(t, kb, source); len(docs) != 1 {
+				t.Errorf("%s has %d chunks, want 1", source, len(docs))
+			}
+		}
+	})
+}
+
+func TestIngestDirectoryCreatesCollection(t *testing.T) {
+	ctx := context.Background()
+	kb, _ := newTestKnowledgeBase(t)
+	dir := t.TempDir()
+	writeDoc(t, dir, "a.txt", "Meloxicam is an NSAID.")
+
+	report, err := kb.IngestDirectory(ctx, "fresh", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Added != 1 {
+		t.Errorf("report = %+v, want one added", report)
+	}
+	collection, err := kb.store.GetCollection(ctx, "fresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model, _ := collectionEmbedder(collection.Metadata); model != "stub" {
+		t.Errorf("collection model = %s, want stub", model)
+	}
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/lucsky/cuid"
+)
+
+// LocalStore is an in-process VectorStore for single-node deployments and
+// tests. Each collection is held in memory, searched by brute force and
+// persisted as one JSON file under dir.
+//
+// Several processes may share dir: writers hold a lock file and reload the
+// collection from disk before changing it, and readers reload a collection
+// whose file another process has replaced.
+type LocalStore struct {
+	dir  string
+	lock *os.File
+
+	// mu guards the collections map. Collections are never modified in
+	// place, so a collection read from the map stays valid without it.
+	mu          sync.RWMutex
+	collections map[string]*localCollection
+}
+
+type localCollection struct {
+	ID       string                 `json:"id"`
+	Name     string                 `json:"name"`
+	Metadata map[string]interface{} `json:"metadata"`
+	Records  []VectorRecord         `json:"records"`
+
+	// file is the file the collection was loaded from or saved to.
+	file os.FileInfo
+}
+
+var _ VectorStore = (*LocalStore)(nil)
+
+var collectionNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{1,61}[a-zA-Z0-9]$`)
+
+// NewLocalStore loads every collection persisted in dir.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating vector store directory: %v", err)
+	}
+
+	lock, err := os.OpenFile(filepath.Join(dir, ".lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening vector store lock: %v", err)
+	}
+
+	s := &LocalStore{dir: dir, lock: lock, collections: make(map[string]*localCollection)}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		c, err := readCollection(f)
+		if err != nil {
+			return nil, err
+		}
+		s.collections[c.Name] = c
+	}
+	return s, nil
+}
+
+func readCollection(path string) (*localCollection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	var c localCollection
+	if err := json.NewDecoder(f).Decode(&c); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	c.file = info
+	return &c, nil
+}
+
+// path returns the file a collection is persisted in. Names are validated
+// here so that no caller can reach outside dir.
+func (s *LocalStore) path(name string) (string, error) {
+	if !collectionNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid collection name %q", name)
+	}
+	return filepath.Join(s.dir, name+".json"), nil
+}
+
+// writeLock excludes other writers, in this process and in any other process
+// sharing dir, until the returned function is called.
+func (s *LocalStore) writeLock() (func(), error) {
+	s.mu.Lock()
+	if err := lockFile(s.lock); err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("error locking vector store: %v", err)
+	}
+	return func() {
+		unlockFile(s.lock)
+		s.mu.Unlock()
+	}, nil
+}
+
+// load rereads a collection from disk, picking up changes made by other
+// processes. Callers hold mu.
+func (s *LocalStore) load(name string) (*localCollection, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	c, err := readCollection(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		delete(s.collections, name)
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.collections[name] = c
+	return c, nil
+}
+
+// current returns a collection for reading, reloading it if its file has been
+// replaced since it was last loaded.
+func (s *LocalStore) current(name string) (*localCollection, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	c, ok := s.collections[name]
+	s.mu.RUnlock()
+
+	info, err := os.Stat(path)
+	if ok && err == nil && sameFile(c.file, info) {
+		return c, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(name)
+}
+
+func sameFile(a, b os.FileInfo) bool {
+	return a != nil && os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// save writes a collection atomically. Callers hold the write lock.
+func (s *LocalStore) save(c *localCollection) error {
+	path, err := s.path(c.Name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".collection-*")
+	if err != nil {
+		return fmt.Errorf("error saving collection: %v", err)
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error saving collection: %v", err)
+	}
+	c.file, err = os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error saving collection: %v", err)
+	}
+	return nil
+}
+
+func (c *localCollection) info() *Collection {
+	metadata := make(map[string]interface{}, len(c.Metadata))
+	for k, v := range c.Metadata {
+		metadata[k] = v
+	}
+	return &Collection{ID: c.ID, Name: c.Name, Metadata: metadata}
+}
+
+func (s *LocalStore) CreateCollection(ctx context.Context, name string, metadata map[string]interface{}) (*Collection, error) {
+	if !collectionNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid collection name %q", name)
+	}
+
+	unlock, err := s.writeLock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if c, err := s.load(name); err == nil {
+		return c.info(), nil
+	} else if !errors.Is(err, ErrCollectionNotFound) {
+		return nil, err
+	}
+	c := &localCollection{ID: cuid.New(), Name: name, Metadata: mutableMetadata(metadata)}
+	if err := s.save(c); err != nil {
+		return nil, err
+	}
+	s.collections[name] = c
+	return c.info(), nil
+}
+
+func (s *LocalStore) GetCollection(ctx context.Context, name string) (*Collection, error) {
+	c, err := s.current(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.info(), nil
+}
+
+func (s *LocalStore) DeleteCollection(ctx context.Context, name string) error {
+	unlock, err := s.writeLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := s.load(name); err != nil {
+		return err
+	}
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete collection: %v", err)
+	}
+	delete(s.collections, name)
+	return nil
+}
+
+func (s *LocalStore) UpdateCollection(ctx context.Context, name string, newName string, metadata map[string]interface{}) error {
+	if !collectionNamePattern.MatchString(newName) {
+		return fmt.Errorf("invalid collection name %q", newName)
+	}
+
+	unlock, err := s.writeLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	c, err := s.load(name)
+	if err != nil {
+		return err
+	}
+	if newName != name {
+		if _, err := s.load(newName); err == nil {
+			return fmt.Errorf("collection %s already exists", newName)
+		} else if !errors.Is(err, ErrCollectionNotFound) {
+			return err
+		}
+	}
+
+	updated := *c
+	updated.Name = newName
+	updated.Metadata = mutableMetadata(metadata)
+	if err := s.save(&updated); err != nil {
+		return err
+	}
+	if newName != name {
+		if path, err := s.path(name); err == nil {
+			os.Remove(path)
+		}
+		delete(s.collections, name)
+	}
+	s.collections[newName] = &updated
+	return nil
+}
+
+func (s *LocalStore) Count(ctx context.Context, name string) (int, error) {
+	c, err := s.current(name)
+	if err != nil {
+		return 0, err
+	}
+	return len(c.Records), nil
+}
+
+func (s *LocalStore) Add(ctx context.Context, name string, records []VectorRecord) error {
+	unlock, err := s.writeLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	c, err := s.load(name)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(c.Records))
+	for _, r := range c.Records {
+		existing[r.ID] = true
+	}
+	for _, r := range records {
+		if existing[r.ID] {
+			return fmt.Errorf("failed to add document: ID %s already exists", r.ID)
+		}
+		if len(c.Records) > 0 && len(r.Embedding) != len(c.Records[0].Embedding) {
+			return fmt.Errorf("failed to add document: embedding has %d dimensions, collection uses %d", len(r.Embedding), len(c.Records[0].Embedding))
+		}
+		existing[r.ID] = true
+	}
+
+	updated := *c
+	updated.Records = append(append([]VectorRecord(nil), c.Records...), records...)
+	if err := s.save(&updated); err != nil {
+		return err
+	}
+	s.collections[name] = &updated
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, name string, where map[string]interface{}, limit int, offset int) ([]VectorRecord, error) {
+	c, err := s.current(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []VectorRecord
+	skipped := 0
+	for _, r := range c.Records {
+		ok, err := matchWhere(r.Metadata, where)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		r.Embedding = nil
+		out = append(out, r)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, name string, ids []string, where map[string]interface{}) error {
+	unlock, err := s.writeLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	c, err := s.load(name)
+	if err != nil {
+		return err
+	}
+
+	idSet := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		idSet[id] = true
+	}
+
+	kept := make([]VectorRecord, 0, len(c.Records))
+	for _, r := range c.Records {
+		match := len(ids) == 0 || idSet[r.ID]
+		if match {
+			ok, err := matchWhere(r.Metadata, where)
+			if err != nil {
+				return err
+			}
+			match = ok
+		}
+		if !match {
+			kept = append(kept, r)
+		}
+	}
+
+	updated := *c
+	updated.Records = kept
+	if err := s.save(&updated); err != nil {
+		return err
+	}
+	s.collections[name] = &updated
+	return nil
+}
+
+func (s *LocalStore) Query(ctx context.Context, name string, embedding []float32, nResults int, where map[string]interface{}) ([]VectorMatch, error) {
+	c, err := s.current(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []VectorMatch
+	for _, r := range c.Records {
+		if len(r.Embedding) != len(embedding) {
+			return nil, fmt.Errorf("query embedding has %d dimensions, collection uses %d", len(embedding), len(r.Embedding))
+		}
+		ok, err := matchWhere(r.Metadata, where)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		m := VectorMatch{VectorRecord: r, Distance: squaredL2(embedding, r.Embedding)}
+		m.Embedding = nil
+		matches = append(matches, m)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Distance < matches[j].Distance })
+	if nResults > 0 && len(matches) > nResults {
+		matches = matches[:nResults]
+	}
+	return matches, nil
+}
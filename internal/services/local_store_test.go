@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestLocalStoreSharedDirectory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	a, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.CreateCollection(ctx, "docs", nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Interleaved writers, standing in for two processes, must not lose
+	// each other's records.
+	var wg sync.WaitGroup
+	for name, store := range map[string]*LocalStore{"a": a, "b": b} {
+		wg.Add(1)
+		go func(name string, store *LocalStore) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				r := VectorRecord{ID: fmt.Sprintf("%s%d", name, i), Document: "text", Embedding: []float32{1, 2}}
+				if err := store.Add(ctx, "docs", []VectorRecord{r}); err != nil {
+					t.Error(err)
+				}
+			}
+		}(name, store)
+	}
+	wg.Wait()
+
+	for name, store := range map[string]*LocalStore{"a": a, "b": b} {
+		if n, err := store.Count(ctx, "docs"); err != nil || n != 40 {
+			t.Errorf("store %s counts %d records (err %v), want 40", name, n, err)
+		}
+	}
+
+	if err := a.Delete(ctx, "docs", []string{"b0"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := b.Get(ctx, "docs", nil, 0, 0); len(got) != 39 {
+		t.Errorf("store b sees %d records after a deleted one, want 39", len(got))
+	}
+
+	if err := a.DeleteCollection(ctx, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.GetCollection(ctx, "docs"); err == nil {
+		t.Error("store b still sees the collection a deleted")
+	}
+}
+
+func TestLocalStoreRejectsPathNames(t *testing.T) {
+	ctx := context.Background()
+	parent := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(parent, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A collection file outside the store directory must stay out of reach.
+	outside := filepath.Join(parent, "secret.json")
+	if err := os.WriteFile(outside, []byte(`{"name":"secret","records":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../secret", "a/../../secret", "/etc/passwd", ""} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.GetCollection(ctx, name); err == nil {
+				t.Error("GetCollection succeeded")
+			}
+			if _, err := store.Count(ctx, name); err == nil {
+				t.Error("Count succeeded")
+			}
+			if err := store.DeleteCollection(ctx, name); err == nil {
+				t.Error("DeleteCollection succeeded")
+			}
+		})
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("file outside the store: %v", err)
+	}
+}
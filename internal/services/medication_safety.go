@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"vet-tails/ai/internal/formulary"
+	"vet-tails/ai/internal/models"
+)
+
+// PatientContext is what SOAP generation knows about the patient the note is
+// for.
+type PatientContext struct {
+	Patient *models.Patient
+	// WeightKg is the current weight, 0 when unknown.
+	WeightKg float64
+	// CurrentMedications are what the patient already takes; plan
+	// medications are checked for interactions with them.
+	CurrentMedications []string
+}
+
+// CheckPlanSafety cross-checks the medications in a note's plan against the
+// patient's record and stores the warnings on the note.
+func CheckPlanSafety(note *models.Note, patient *PatientContext) []models.SafetyWarning {
+	warnings := []models.SafetyWarning{}
+	if patient != nil && patient.Patient != nil {
+		warnings = append(warnings, allergyWarnings(patient.Patient.Allergies, planMedications(&note.Plan))...)
+		warnings = append(warnings, doseWarnings(patient, note.Plan.Medications)...)
+	}
+	var current []string
+	if patient != nil {
+		current = patient.CurrentMedications
+	}
+	warnings = append(warnings, interactionWarnings(current, planMedications(&note.Plan))...)
+	note.SafetyWarnings = warnings
+	return warnings
+}
+
+// planMedications returns the plan entries that may name a drug. Immediate
+// treatments are included since in-clinic injections are often listed there.
+func planMedications(plan *models.SOAPPlan) []string {
+	medications := make([]string, 0, len(plan.Medications)+len(plan.ImmediateTreatment))
+	for _, m := range plan.Medications {
+		medications = append(medications, m.String())
+	}
+	return append(medications, plan.ImmediateTreatment...)
+}
+
+func allergyWarnings(allergies []models.PatientAllergy, medications []string) []models.SafetyWarning {
+	allergens := make([]formulary.Allergen, len(allergies))
+	for i, a := range allergies {
+		allergens[i] = formulary.Allergen{Name: a.Name(), Severity: a.Severity}
+	}
+
+	var warnings []models.SafetyWarning
+	for _, c := range formulary.CheckAllergies(allergens, medications) {
+		severity := models.SafetyCritical
+		if !c.Blocking {
+			severity = models.SafetyModerate
+		}
+		warnings = append(warnings, models.SafetyWarning{
+			Type:       models.SafetyAllergy,
+			Severity:   severity,
+			Blocking:   c.Blocking,
+			Medication: c.Medication,
+			Drug:       c.Drug,
+			Match:      c.Match,
+			Allergy:    c.Allergy,
+			Message:    c.Message,
+		})
+	}
+	return warnings
+}
+
+// doseWarnings checks each order against the species formulary: species
+// contraindications always, doses when the patient's weight is known.
+func doseWarnings(patient *PatientContext, orders []models.MedicationOrder) []models.SafetyWarning {
+	var warnings []models.SafetyWarning
+	unchecked := false
+	for _, order := range orders {
+		if !order.InDictionary {
+			continue
+		}
+		result, err := formulary.CalculateDose(formulary.DoseRequest{
+			Drug:      order.Drug,
+			Species:   patient.Patient.Species,
+			WeightKg:  patient.WeightKg,
+			Dose:      order.Dose,
+			Unit:      order.Unit,
+			Strength:  order.Strength,
+			Frequency: order.Frequency,
+		})
+		switch {
+		case errors.Is(err, formulary.ErrInvalidWeight):
+			unchecked = unchecked || order.Dose > 0
+			continue
+		case errors.Is(err, formulary.ErrUnconvertedDose):
+			warnings = append(warnings, models.SafetyWarning{
+				Type:       models.SafetyDose,
+				Severity:   models.SafetyMinor,
+				Medication: order.String(),
+				Drug:       order.Drug,
+				SafeRange:  result.SafeRange(),
+				Message:    fmt.Sprintf("Dose of %g %s could not be checked; give the product strength", order.Dose, order.Unit),
+			})
+			continue
+		case err != nil:
+			// No formulary data for this drug or species.
+			continue
+		}
+
+		warning := models.SafetyWarning{
+			Type:       models.SafetyDose,
+			Medication: order.String(),
+			Drug:       order.Drug,
+			SafeRange:  result.SafeRange(),
+			Message:    result.Message,
+		}
+		switch result.Status {
+		case formulary.DoseContraindicated:
+			warning.Type = models.SafetyContraindication
+			warning.Severity = models.SafetyCritical
+			warning.Blocking = true
+		case formulary.DoseAboveMax:
+			warning.Severity = models.SafetyCritical
+			warning.Blocking = true
+		case formulary.DoseAboveRange:
+			warning.Severity = models.SafetyMajor
+			warning.Blocking = true
+		case formulary.DoseBelowRange:
+			warning.Severity = models.SafetyModerate
+		default:
+			continue
+		}
+		warnings = append(warnings, warning)
+	}
+	if unchecked {
+		warnings = append(warnings, models.SafetyWarning{
+			Type:     models.SafetyDose,
+			Severity: models.SafetyMinor,
+			Message:  "No weight is recorded for the patient, so doses were not checked",
+		})
+	}
+	return warnings
+}
+
+var interactionSeverity = map[string]string{
+	formulary.InteractionContraindicated: models.SafetyCritical,
+	formulary.InteractionMajor:           models.SafetyMajor,
+	formulary.InteractionModerate:        models.SafetyModerate,
+	formulary.InteractionMinor:           models.SafetyMinor,
+}
+
+// interactionWarnings checks the plan's medications together with the
+// current ones. Contraindicated and major interactions block when the plan
+// introduces one of the drugs; interactions among current medications are
+// reported for review only.
+func interactionWarnings(current, proposed []string) []models.SafetyWarning {
+	var warnings []models.SafetyWarning
+	for _, a := range formulary.CheckInteractions(current, proposed) {
+		severity := interactionSeverity[a.Severity]
+		blocking := a.Proposed && (severity == models.SafetyCritical || severity == models.SafetyMajor)
+		message := fmt.Sprintf("%s + %s: %s. %s", a.DrugA, a.DrugB, a.Effect, a.Management)
+		if !a.Proposed {
+			message = "Among current medications, " + message
+		}
+		warnings = append(warnings, models.SafetyWarning{
+			Type:       models.SafetyInteraction,
+			Severity:   severity,
+			Blocking:   blocking,
+			Medication: a.DrugB,
+			Drug:       a.DrugB,
+			Interacts:  []string{a.DrugA},
+			Message:    message,
+		})
+	}
+	return warnings
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+	"vet-tails/ai/internal/models"
+)
+
+func TestAllergyWarningsUsePatientSeverity(t *testing.T) {
+	penicillin := models.Allergy{ID: 1, Name: "penicillin", Severity: "mild"}
+	tests := []struct {
+		severity string
+		blocking bool
+	}{
+		{"mild", false},
+		{"severe - anaphylaxis", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			allergies := []models.PatientAllergy{{AllergyID: penicillin.ID, Allergy: penicillin, Severity: tt.severity}}
+			warnings := allergyWarnings(allergies, []string{"cephalexin 22 mg/kg PO q12h"})
+			if len(warnings) != 1 {
+				t.Fatalf("warnings = %+v, want one", warnings)
+			}
+			if warnings[0].Blocking != tt.blocking {
+				t.Errorf("blocking = %v, want %v", warnings[0].Blocking, tt.blocking)
+			}
+		})
+	}
+}
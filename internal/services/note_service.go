@@ -0,0 +1,485 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"vet-tails/ai/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoteNotFound     = errors.New("note not found")
+	ErrNoteSigned       = errors.New("note is signed and can no longer be changed")
+	ErrNoteConflict     = errors.New("note was changed by someone else; reload it and try again")
+	ErrVersionNotFound  = errors.New("note version not found")
+	ErrInvalidEdit      = errors.New("invalid note edit")
+	ErrNoTranscript     = errors.New("note has no transcript to regenerate from")
+	ErrBlockingWarnings = errors.New("note has blocking safety warnings; resolve them or sign with an override reason")
+	ErrModelOutput      = errors.New("model did not return a usable section")
+)
+
+// NoteService keeps SOAP notes as editable drafts with a full version
+// history until a veterinarian signs them.
+type NoteService struct {
+	db   *gorm.DB
+	soap *SOAPService
+}
+
+func NewNoteService(db *gorm.DB, soap *SOAPService) *NoteService {
+	return &NoteService{db: db, soap: soap}
+}
+
+// NoteEdit changes fields of a draft. Each section holds only the fields to
+// replace; fields not given keep their value, at any depth.
+type NoteEdit struct {
+	Editor  string
+	Comment string
+	// BaseVersion, when set, is the version the edit was made against. The
+	// edit is refused if the note has moved on since.
+	BaseVersion int
+	Subjective  json.RawMessage
+	Objective   json.RawMessage
+	Assessment  json.RawMessage
+	Plan        json.RawMessage
+}
+
+// Create stores a generated note as a draft, recording it as version 1.
+func (s *NoteService) Create(note *models.Note) error {
+	note.Status = models.NoteStatusDraft
+	note.Version = 1
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(note).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.NoteVersion{
+			NoteID:  note.ID,
+			Version: 1,
+			Action:  models.NoteActionGenerated,
+			Author:  models.NoteAuthorAI,
+			Changes: []models.FieldChange{},
+			Content: note.Content(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("error saving note: %v", err)
+	}
+	return nil
+}
+
+// GetNote returns a note.
+func (s *NoteService) GetNote(id uint) (*models.Note, error) {
+	var note models.Note
+	if err := s.db.First(&note, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoteNotFound
+		}
+		return nil, fmt.Errorf("error loading note: %v", err)
+	}
+	return &note, nil
+}
+
+// Versions returns a note's history, oldest first.
+func (s *NoteService) Versions(id uint) ([]models.NoteVersion, error) {
+	if _, err := s.GetNote(id); err != nil {
+		return nil, err
+	}
+	var versions []models.NoteVersion
+	if err := s.db.Where("note_id = ?", id).Order("version ASC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("error loading note versions: %v", err)
+	}
+	return versions, nil
+}
+
+// Diff compares two versions of a note. A zero from is the generated draft
+// and a zero to is the latest version.
+func (s *NoteService) Diff(id uint, from, to int) (*models.NoteVersion, *models.NoteVersion, []models.FieldChange, error) {
+	versions, err := s.Versions(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(versions) == 0 {
+		return nil, nil, nil, ErrVersionNotFound
+	}
+	if from == 0 {
+		from = versions[0].Version
+	}
+	if to == 0 {
+		to = versions[len(versions)-1].Version
+	}
+	var a, b *models.NoteVersion
+	for i := range versions {
+		if versions[i].Version == from {
+			a = &versions[i]
+		}
+		if versions[i].Version == to {
+			b = &versions[i]
+		}
+	}
+	if a == nil || b == nil {
+		return nil, nil, nil, ErrVersionNotFound
+	}
+	changes, err := models.DiffContent(a.Content, b.Content)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error comparing note versions: %v", err)
+	}
+	return a, b, changes, nil
+}
+
+// Edit applies an edit to a draft and records the changed fields as a new
+// version. Medications and vital signs are normalized again and the plan's
+// safety rechecked against patient. An edit that changes nothing returns the
+// note without a new version.
+func (s *NoteService) Edit(id uint, edit NoteEdit, patient *PatientContext) (*models.Note, []models.FieldChange, error) {
+	return s.edit(id, edit, patient, models.NoteActionEdited, edit.apply)
+}
+
+// Regenerate rewrites one section of a draft from the note's transcript,
+// keeping the other sections as they are, and records it as a new version
+// by editor. The generated section replaces the old one entirely; fields the
+// model leaves out are cleared. If the model fails or returns something that
+// is not a section, ErrModelOutput is returned. If the note changes while the
+// section is being generated the result is discarded with ErrNoteConflict.
+func (s *NoteService) Regenerate(id uint, section string, instructions string, editor string, patient *PatientContext) (*models.Note, []models.FieldChange, error) {
+	note, err := s.GetNote(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if note.IsSigned() {
+		return nil, nil, ErrNoteSigned
+	}
+	if !slices.Contains(models.SOAPSections, section) {
+		return nil, nil, fmt.Errorf("%w: unknown section %q, expected one of %s", ErrInvalidEdit, section, strings.Join(models.SOAPSections, ", "))
+	}
+	if strings.TrimSpace(note.Transcript) == "" {
+		return nil, nil, ErrNoTranscript
+	}
+
+	raw, err := s.soap.RegenerateSection(note, section, instructions, patient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrModelOutput, err)
+	}
+	var generated models.NoteContent
+	if err := decodeSection(&generated, section, raw); err != nil {
+		return nil, nil, err
+	}
+	replace := func(content *models.NoteContent) error {
+		switch section {
+		case models.SectionSubjective:
+			content.Subjective = generated.Subjective
+		case models.SectionObjective:
+			content.Objective = generated.Objective
+		case models.SectionAssessment:
+			content.Assessment = generated.Assessment
+		case models.SectionPlan:
+			content.Plan = generated.Plan
+		}
+		return nil
+	}
+	edit := NoteEdit{Editor: editor, Comment: instructions, BaseVersion: note.Version}
+	return s.edit(id, edit, patient, models.NoteActionRegenerated, replace)
+}
+
+// apply merges the edit's sections into content.
+func (e NoteEdit) apply(content *models.NoteContent) error {
+	var err error
+	if content.Subjective, err = mergeSection("subjective", content.Subjective, e.Subjective); err != nil {
+		return err
+	}
+	if content.Objective, err = mergeSection("objective", content.Objective, e.Objective); err != nil {
+		return err
+	}
+	if content.Assessment, err = mergeSection("assessment", content.Assessment, e.Assessment); err != nil {
+		return err
+	}
+	content.Plan, err = mergeSection("plan", content.Plan, e.Plan)
+	return err
+}
+
+// edit changes a draft's content with change and records the result as a
+// version by edit.Editor.
+func (s *NoteService) edit(id uint, edit NoteEdit, patient *PatientContext, action string, change func(*models.NoteContent) error) (*models.Note, []models.FieldChange, error) {
+	var note models.Note
+	var changes []models.FieldChange
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockDraft(tx, &note, id, edit.BaseVersion); err != nil {
+			return err
+		}
+		before := note.Content()
+
+		content := before
+		err := change(&content)
+		if err != nil {
+			return err
+		}
+		note.SetContent(content)
+		prepareNote(&note, patient)
+
+		changes, err = models.DiffContent(before, note.Content())
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		return s.saveVersion(tx, &note, models.NoteVersion{Action: action, Author: edit.Editor, Comment: edit.Comment, Changes: changes},
+			"subjective", "objective", "assessment", "plan", "safety_warnings", "evidence")
+	})
+	if err != nil {
+		return nil, nil, noteError(err)
+	}
+	return &note, changes, nil
+}
+
+// AttachImageFindings adds clinical image analyses to a draft's objective
+// section and records them as a new version by author.
+func (s *NoteService) AttachImageFindings(id uint, author string, analyses ...models.ClinicalImageAnalysis) (*models.Note, error) {
+	var note models.Note
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockDraft(tx, &note, id, 0); err != nil {
+			return err
+		}
+		before := note.Content()
+		note.AttachImageFindings(analyses...)
+		note.LinkEvidence()
+		changes, err := models.DiffContent(before, note.Content())
+		if err != nil {
+			return err
+		}
+		return s.saveVersion(tx, &note, models.NoteVersion{Action: models.NoteActionImagesAttached, Author: author, Changes: changes},
+			"objective", "evidence")
+	})
+	if err != nil {
+		return nil, noteError(err)
+	}
+	return &note, nil
+}
+
+// Sign locks a draft, recording the signing veterinarian and time. A note
+// with blocking safety warnings is only signed when the veterinarian gives
+// an override reason, which is recorded in the version.
+func (s *NoteService) Sign(id uint, veterinarian string, comment string, override string) (*models.Note, error) {
+	var note models.Note
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockDraft(tx, &note, id, 0); err != nil {
+			return err
+		}
+		override = strings.TrimSpace(override)
+		if !note.HasBlockingWarnings() {
+			override = ""
+		} else if override == "" {
+			return fmt.Errorf("%w: %s", ErrBlockingWarnings, blockingSummary(&note))
+		}
+		now := time.Now()
+		note.Status = models.NoteStatusSigned
+		note.SignedBy = veterinarian
+		note.SignedAt = &now
+		return s.saveVersion(tx, &note, models.NoteVersion{
+			Action:   models.NoteActionSigned,
+			Author:   veterinarian,
+			Comment:  comment,
+			Override: override,
+			Changes:  []models.FieldChange{},
+		},
+			"status", "signed_by", "signed_at")
+	})
+	if err != nil {
+		return nil, noteError(err)
+	}
+	return &note, nil
+}
+
+// lockDraft loads a note that may still be changed. Notes stored before
+// versioning get their current content recorded as version 1 first, so the
+// history always starts from the generated draft.
+func (s *NoteService) lockDraft(tx *gorm.DB, note *models.Note, id uint, baseVersion int) error {
+	if err := tx.First(note, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoteNotFound
+		}
+		return err
+	}
+	if note.IsSigned() {
+		return ErrNoteSigned
+	}
+	if baseVersion != 0 && baseVersion != note.Version {
+		return fmt.Errorf("%w: editing version %d but the note is at version %d", ErrNoteConflict, baseVersion, note.Version)
+	}
+	if note.Version > 0 {
+		return nil
+	}
+	if err := tx.Create(&models.NoteVersion{
+		NoteID:    note.ID,
+		Version:   1,
+		Action:    models.NoteActionGenerated,
+		Author:    models.NoteAuthorAI,
+		Changes:   []models.FieldChange{},
+		Content:   note.Content(),
+		CreatedAt: note.CreatedAt,
+	}).Error; err != nil {
+		return err
+	}
+	res := tx.Model(note).Where("version = ?", 0).Update("version", 1)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNoteConflict
+	}
+	note.Version = 1
+	return nil
+}
+
+// saveVersion bumps the note's version, updating columns only if nobody
+// else did in the meantime, and records version with the note's content.
+func (s *NoteService) saveVersion(tx *gorm.DB, note *models.Note, version models.NoteVersion, columns ...string) error {
+	from := note.Version
+	note.Version++
+	res := tx.Model(note).Where("version = ?", from).
+		Select(append(columns, "version", "updated_at")).
+		Updates(note)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNoteConflict
+	}
+	version.NoteID = note.ID
+	version.Version = note.Version
+	version.Content = note.Content()
+	return tx.Create(&version).Error
+}
+
+// mergeSection replaces the fields given in patch, keeping the rest of the
+// section. Objects are merged recursively, so a patch of one vital sign
+// keeps the others.
+func mergeSection[T any](name string, section T, patch json.RawMessage) (T, error) {
+	if len(patch) == 0 || string(patch) == "null" {
+		return section, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return section, fmt.Errorf("%w: %s must be an object", ErrInvalidEdit, name)
+	}
+	current, err := json.Marshal(section)
+	if err != nil {
+		return section, err
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(current, &merged); err != nil {
+		return section, err
+	}
+	if err := mergeObjects(merged, fields); err != nil {
+		return section, fmt.Errorf("%w: %s: %v", ErrInvalidEdit, name, err)
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return section, err
+	}
+	var out T
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&out); err != nil {
+		return section, fmt.Errorf("%w: %s: %v", ErrInvalidEdit, name, err)
+	}
+	return out, nil
+}
+
+// mergeObjects merges patch into current, recursing into fields that are
+// objects in both. Other fields, arrays included, are replaced. When a
+// measurement's value or unit changes, its original text and rejection
+// reason describe the old reading and are dropped.
+func mergeObjects(current, patch map[string]json.RawMessage) error {
+	before := map[string]json.RawMessage{"value": current["value"], "unit": current["unit"]}
+	for k, v := range patch {
+		if !isJSONObject(current[k]) || !isJSONObject(v) {
+			current[k] = v
+			continue
+		}
+		var a, b map[string]json.RawMessage
+		if err := json.Unmarshal(current[k], &a); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(v, &b); err != nil {
+			return err
+		}
+		if err := mergeObjects(a, b); err != nil {
+			return err
+		}
+		data, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		current[k] = data
+	}
+
+	_, hasOriginal := patch["original"]
+	if hasOriginal {
+		return nil
+	}
+	for _, k := range []string{"value", "unit"} {
+		if _, ok := patch[k]; ok && !sameJSON(before[k], current[k]) {
+			delete(current, "original")
+			delete(current, "rejected")
+			break
+		}
+	}
+	return nil
+}
+
+func isJSONObject(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '{'
+}
+
+// sameJSON reports whether two JSON values are equal, so 39 and 39.0 match.
+func sameJSON(a, b json.RawMessage) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// decodeSection decodes a generated section into its place in content. Keys
+// the section does not have are ignored, since models often add some.
+func decodeSection(content *models.NoteContent, section string, raw json.RawMessage) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '{' {
+		return fmt.Errorf("%w: %s is not an object", ErrModelOutput, section)
+	}
+	data, err := json.Marshal(map[string]json.RawMessage{section: raw})
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrModelOutput, section, err)
+	}
+	if err := json.Unmarshal(data, content); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrModelOutput, section, err)
+	}
+	return nil
+}
+
+// blockingSummary lists the note's blocking warnings for an error message.
+func blockingSummary(note *models.Note) string {
+	var messages []string
+	for _, w := range note.SafetyWarnings {
+		if w.Blocking {
+			messages = append(messages, w.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+func noteError(err error) error {
+	for _, known := range []error{ErrNoteNotFound, ErrNoteSigned, ErrNoteConflict, ErrInvalidEdit, ErrNoTranscript, ErrBlockingWarnings, ErrModelOutput} {
+		if errors.Is(err, known) {
+			return err
+		}
+	}
+	return fmt.Errorf("error updating note: %v", err)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"vet-tails/ai/internal/models"
+)
+
+func TestDecodeSection(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr error
+	}{
+		{"section", `{"immediate_treatment": ["IV fluids"], "medications": []}`, nil},
+		{"extra keys", `{"immediate_treatment": ["IV fluids"], "rationale": "dehydrated"}`, nil},
+		{"null", `null`, ErrModelOutput},
+		{"text", `"give fluids"`, ErrModelOutput},
+		{"wrong type", `{"immediate_treatment": "IV fluids"}`, ErrModelOutput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var content models.NoteContent
+			err := decodeSection(&content, models.SectionPlan, json.RawMessage(tt.raw))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(content.Plan.ImmediateTreatment) != 1 || content.Plan.FollowUp != "" {
+				t.Errorf("plan = %+v, want only the generated treatment", content.Plan)
+			}
+		})
+	}
+}
+
+func TestMergeSection(t *testing.T) {
+	objective := models.SOAPObjective{ExaminationFindings: []string{"mild dehydration"}}
+	objective.VitalSigns.Temperature = models.ParseMeasurement("102.5 F")
+	objective.VitalSigns.HeartRate = models.ParseMeasurement("120 bpm")
+	objective.VitalSigns.MucousMembranes = "pink, moist"
+
+	tests := []struct {
+		name         string
+		patch        string
+		tempOriginal string
+		tempValue    float64
+		check        func(t *testing.T, got models.SOAPObjective)
+	}{
+		{
+			name:         "nested field keeps siblings",
+			patch:        `{"vital_signs": {"temperature": "39 C"}}`,
+			tempOriginal: "39 C",
+			tempValue:    39,
+			check: func(t *testing.T, got models.SOAPObjective) {
+				if got.VitalSigns.HeartRate.Original != "120 bpm" || got.VitalSigns.MucousMembranes != "pink, moist" {
+					t.Errorf("other vital signs changed: %+v", got.VitalSigns)
+				}
+				if len(got.ExaminationFindings) != 1 {
+					t.Errorf("examination findings changed: %v", got.ExaminationFindings)
+				}
+			},
+		},
+		{
+			name:      "changed value drops stale original",
+			patch:     `{"vital_signs": {"temperature": {"value": 39, "unit": "C"}}}`,
+			tempValue: 39,
+		},
+		{
+			name:         "unchanged value keeps original",
+			patch:        `{"vital_signs": {"temperature": {"value": 102.5}}}`,
+			tempOriginal: "102.5 F",
+			tempValue:    102.5,
+		},
+		{
+			name:         "arrays are replaced",
+			patch:        `{"examination_findings": ["normal"]}`,
+			tempOriginal: "102.5 F",
+			tempValue:    102.5,
+			check: func(t *testing.T, got models.SOAPObjective) {
+				if len(got.ExaminationFindings) != 1 || got.ExaminationFindings[0] != "normal" {
+					t.Errorf("examination findings = %v, want [normal]", got.ExaminationFindings)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeSection("objective", objective, json.RawMessage(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			temp := got.VitalSigns.Temperature
+			if temp.Value == nil || *temp.Value != tt.tempValue || temp.Original != tt.tempOriginal {
+				t.Errorf("temperature = %+v, want value %v original %q", temp, tt.tempValue, tt.tempOriginal)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+
+	if _, err := mergeSection("objective", objective, json.RawMessage(`{"vital_signs": {"pulse": 80}}`)); !errors.Is(err, ErrInvalidEdit) {
+		t.Errorf("unknown field: err = %v, want ErrInvalidEdit", err)
+	}
+}
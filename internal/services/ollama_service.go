@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"vet-tails/ai/internal/models"
 )
 
@@ -17,6 +19,7 @@ type LlavaRequest struct {
 	Model       string   `json:"model"`
 	Prompt      string   `json:"prompt"`
 	Stream      bool     `json:"stream"`
+	Format      string   `json:"format,omitempty"`
 	Temperature float32  `json:"temperature"`
 	Images      []string `json:"images"`
 }
@@ -32,31 +35,118 @@ func NewLlavaService(baseURL string) *LlavaService {
 	}
 }
 
-func (s *LlavaService) DetectBreed(image string) (*models.BreedDetection, error) {
+// MaxBreedImages caps how many photos are analysed together.
+const MaxBreedImages = 6
+
+// breedAnalysis is the model's answer for a set of photos.
+type breedAnalysis struct {
+	Assessments           []models.ImageBreedAssessment `json:"assessments"`
+	HealthPredispositions []models.HealthPredisposition `json:"health_predispositions"`
+}
+
+// DetectBreed analyses one or more photos of the same animal. The model
+// assesses each photo separately and the assessments are combined into a
+// consensus. views optionally labels each image (face, profile, coat...).
+func (s *LlavaService) DetectBreed(images []string, views []string) (*models.BreedConsensus, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images provided")
+	}
+	if len(images) > MaxBreedImages {
+		return nil, fmt.Errorf("at most %d images can be analysed together", MaxBreedImages)
+	}
+
+	var imageList strings.Builder
+	for i := range images {
+		view := "unspecified view"
+		if i < len(views) && strings.TrimSpace(views[i]) != "" {
+			view = strings.TrimSpace(views[i])
+		}
+		fmt.Fprintf(&imageList, "    - Image %d: %s\n", i+1, view)
+	}
+
 	// Prepare Ollama request
-	prompt := `Analyze this pet image as a professional veterinarian:
-    1. What breed do you see? Be specific.
-    2. What visual characteristics support this identification?
-    3. Rate your confidence level (0-100%).
-    4. List any possible alternative breeds if unsure.
-    
-    Format your response as:
-    Primary Breed: [breed name]
-    Confidence: [percentage]
-    Key Features: [list key identifying features]
-    Alternative Breeds: [if applicable]`
+	prompt := fmt.Sprintf(`You are given %d photos of the same animal:
+%s
+    Analyze each photo separately as a professional veterinarian:
+    1. What species is this animal (dog, cat, rabbit or other)?
+    2. What breed or breeds do you see? Be specific. Most patients are mixed
+       breeds: list every breed that contributes to the animal's appearance,
+       ranked by estimated proportion, with proportions summing to 1.
+    3. What visible characteristics in this photo support the identification?
+    4. Rate your confidence between 0 and 1 for the species, for each breed and
+       for the overall identification from this photo alone.
+    Then list health conditions the breeds are predisposed to, with the breeds
+    each one is associated with and a risk level (low, moderate or high).
+
+    Respond only with valid JSON matching this example, with one entry in
+    "assessments" per photo, in order:
+    {
+        "assessments": [
+            {
+                "image_index": 1,
+                "view": "face",
+                "species": "dog",
+                "species_confidence": 0.98,
+                "primary_breed": "Labrador Retriever",
+                "confidence": 0.7,
+                "candidates": [
+                    {"breed": "Labrador Retriever", "proportion": 0.6, "confidence": 0.8},
+                    {"breed": "Border Collie", "proportion": 0.4, "confidence": 0.5}
+                ],
+                "visible_features": ["feature1", "feature2"]
+            }
+        ],
+        "health_predispositions": [
+            {
+                "condition": "Hip dysplasia",
+                "associated_with": ["Labrador Retriever"],
+                "risk": "moderate",
+                "notes": "relevant screening or monitoring"
+            }
+        ]
+    }`, len(images), imageList.String())
+
+	var analysis breedAnalysis
+	if err := s.generate(prompt, images, &analysis); err != nil {
+		return nil, fmt.Errorf("error parsing breed detection: %v", err)
+	}
+
+	var assessments []models.ImageBreedAssessment
+	for i, a := range analysis.Assessments {
+		a.Normalize()
+		if a.PrimaryBreed == "" {
+			continue
+		}
+		if a.ImageIndex < 1 || a.ImageIndex > len(images) {
+			a.ImageIndex = i + 1
+		}
+		if a.View == "" && a.ImageIndex <= len(views) {
+			a.View = views[a.ImageIndex-1]
+		}
+		assessments = append(assessments, a)
+	}
+	if len(assessments) == 0 {
+		return nil, fmt.Errorf("error parsing breed detection: no breed identified")
+	}
+
+	return models.AggregateBreedAssessments(assessments, analysis.HealthPredispositions), nil
+}
 
+// generate sends a prompt and images to the vision model and decodes its JSON
+// answer into out.
+func (s *LlavaService) generate(prompt string, images []string, out interface{}) error {
 	reqBody := LlavaRequest{
-		Model:       "llava",
+		Model:       s.model,
 		Prompt:      prompt,
-		Temperature: 0.7,
-		Images:      []string{image},
+		Format:      "json",
+		Temperature: 0.2,
+		Images:      images,
 		Stream:      false,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %v", err)
+		return fmt.Errorf("error marshaling request: %v", err)
 	}
 
 	resp, err := http.Post(
@@ -65,22 +155,34 @@ func (s *LlavaService) DetectBreed(image string) (*models.BreedDetection, error)
 		bytes.NewBuffer(jsonData),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("error calling Ollama API: %v", err)
+		return fmt.Errorf("error calling Ollama API: %v", err)
 	}
 	defer resp.Body.Close()
 
-	var result OllamaResponse
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Ollama API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var result LlavaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+		return fmt.Errorf("error decoding response: %v", err)
 	}
 
-	// Parse the JSON response into Note structure
-	var note models.BreedDetection
-	if err := json.Unmarshal([]byte(result.Response), &note); err != nil {
+	if err := parseJSONResponse(result.Response, out); err != nil {
 		// Log the response for debugging
 		fmt.Printf("Raw response: %s\n", result.Response)
-		return nil, fmt.Errorf("error parsing SOAP note: %v", err)
+		return err
 	}
+	return nil
+}
 
-	return &note, nil
+// parseJSONResponse decodes a model answer, tolerating markdown code fences
+// and prose around the JSON object.
+func parseJSONResponse(raw string, out interface{}) error {
+	text := strings.TrimSpace(raw)
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		text = text[start : end+1]
+	}
+	return json.Unmarshal([]byte(text), out)
 }
@@ -0,0 +1,448 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vet-tails/ai/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrPatientNotFound = errors.New("patient not found")
+
+// Token budget for summary prompts. The history gets whatever is left of the
+// model's context after the prompt template and the answer.
+const (
+	summaryPromptTokens   = 600
+	summaryResponseTokens = 1024
+)
+
+// PatientSummaryService builds patient summaries from the stored history and
+// caches them until the history changes.
+type PatientSummaryService struct {
+	db   *gorm.DB
+	soap *SOAPService
+}
+
+func NewPatientSummaryService(db *gorm.DB, soap *SOAPService) *PatientSummaryService {
+	return &PatientSummaryService{db: db, soap: soap}
+}
+
+// Summarize returns the patient's summary, generating it when the cached one
+// is missing or stale, or when refresh is set. cached reports whether the
+// stored summary was returned.
+func (s *PatientSummaryService) Summarize(patientID uint, refresh bool) (summary *models.PatientSummary, cached bool, err error) {
+	var patient models.Patient
+	if err := s.db.Preload("Allergies.Allergy").First(&patient, patientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrPatientNotFound
+		}
+		return nil, false, fmt.Errorf("error loading patient: %v", err)
+	}
+
+	var notes []models.Note
+	if err := s.db.Where("patient_id = ?", patient.ID).Order("created_at ASC, id ASC").Find(&notes).Error; err != nil {
+		return nil, false, fmt.Errorf("error loading notes: %v", err)
+	}
+
+	history := FormatPatientHistory(&patient, notes)
+	fingerprint := historyFingerprint(history)
+
+	var stored models.PatientSummary
+	if err := s.db.Where("patient_id = ?", patient.ID).Limit(1).Find(&stored).Error; err != nil {
+		return nil, false, fmt.Errorf("error loading summary: %v", err)
+	}
+	if !refresh && stored.ID != 0 && stored.Fingerprint == fingerprint {
+		return &stored, true, nil
+	}
+
+	if EstimateTokens(history) > s.historyBudget() {
+		history, err = s.condensedHistory(&patient, notes)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	summary, err = s.soap.GeneratePatientSummary(history)
+	if err != nil {
+		return nil, false, err
+	}
+	summary.ID = stored.ID
+	summary.PatientID = patient.ID
+	summary.Name = patient.Name
+	summary.Breed = patient.Breed
+	summary.DateOfBirth = patient.DateOfBirth
+	summary.Alerts = withAllergyAlerts(summary.Alerts, patient.Allergies)
+	summary.Fingerprint = fingerprint
+	summary.CreatedAt = stored.CreatedAt
+
+	if err := s.db.Save(summary).Error; err != nil {
+		return nil, false, fmt.Errorf("error saving summary: %v", err)
+	}
+	return summary, false, nil
+}
+
+// CurrentMedications lists what the patient is taking: the current
+// medications of the stored summary plus orders from stored notes whose
+// course has not ended. Orders from the note exceptNoteID are left out, so a
+// note being edited is not checked against its own earlier plan.
+func (s *PatientSummaryService) CurrentMedications(patientID uint, exceptNoteID uint) ([]string, error) {
+	var medications []string
+
+	var summaries []models.PatientSummary
+	if err := s.db.Where("patient_id = ?", patientID).Limit(1).Find(&summaries).Error; err != nil {
+		return nil, fmt.Errorf("error loading summary: %v", err)
+	}
+	for _, summary := range summaries {
+		for _, m := range summary.Medications {
+			medications = append(medications, strings.TrimSpace(m.Name+" "+m.Dosage))
+		}
+	}
+
+	var notes []models.Note
+	if err := s.db.Where("patient_id = ?", patientID).Order("created_at ASC, id ASC").Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("error loading notes: %v", err)
+	}
+	return append(medications, activeOrders(notes, exceptNoteID, time.Now())...), nil
+}
+
+// activeOrders lists the medication orders from notes that are still running
+// at now. Orders without a duration are open-ended and count as ongoing; only
+// a finite course that has ended is left out.
+func activeOrders(notes []models.Note, exceptNoteID uint, now time.Time) []string {
+	var orders []string
+	for _, n := range notes {
+		if n.ID == exceptNoteID {
+			continue
+		}
+		for _, m := range n.Plan.Medications {
+			if m.DurationDays == 0 || n.CreatedAt.AddDate(0, 0, m.DurationDays).After(now) {
+				orders = append(orders, m.String())
+			}
+		}
+	}
+	return orders
+}
+
+// FormatPatientHistory renders the patient record, allergies, medications and
+// notes as the plain-text history the summary prompt expects.
+func FormatPatientHistory(patient *models.Patient, notes []models.Note) string {
+	var b strings.Builder
+	b.WriteString(formatPatientHeader(patient))
+
+	b.WriteString("\nMedications prescribed:\n")
+	prescribed := false
+	for _, n := range notes {
+		for _, m := range n.Plan.Medications {
+			fmt.Fprintf(&b, "- %s: %s\n", n.CreatedAt.Format("2006-01-02"), m)
+			prescribed = true
+		}
+	}
+	if !prescribed {
+		b.WriteString("- none recorded\n")
+	}
+
+	b.WriteString("\nVisits:\n")
+	if len(notes) == 0 {
+		b.WriteString("- no visits recorded\n")
+	}
+	for _, n := range notes {
+		b.WriteString(FormatNote(&n))
+	}
+	return b.String()
+}
+
+// formatPatientHeader renders the patient record and allergies.
+func formatPatientHeader(patient *models.Patient) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Patient: %s\n", patient.Name)
+	if patient.Species != "" {
+		fmt.Fprintf(&b, "Species: %s\n", patient.Species)
+	}
+	if patient.Breed != "" {
+		fmt.Fprintf(&b, "Breed: %s\n", patient.Breed)
+	}
+	if !patient.DateOfBirth.IsZero() {
+		fmt.Fprintf(&b, "Date of birth: %s\n", patient.DateOfBirth.Format("2006-01-02"))
+	}
+
+	b.WriteString("\nAllergies:\n")
+	if len(patient.Allergies) == 0 {
+		b.WriteString("- none recorded\n")
+	}
+	for _, a := range patient.Allergies {
+		fmt.Fprintf(&b, "- %s", a.Name())
+		if a.Severity != "" {
+			fmt.Fprintf(&b, " (%s)", a.Severity)
+		}
+		if a.Description != "" {
+			fmt.Fprintf(&b, ": %s", a.Description)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// FormatNote renders one SOAP note as a dated visit entry.
+func FormatNote(n *models.Note) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n--- Visit %s ---\n", n.CreatedAt.Format("2006-01-02"))
+	writeField(&b, "Chief complaint", n.Subjective.ChiefComplaint)
+	writeField(&b, "History", n.Subjective.History)
+	writeList(&b, "Symptoms", n.Subjective.Symptoms)
+	writeList(&b, "Examination findings", n.Objective.ExaminationFindings)
+	writeField(&b, "Diagnosis", n.Assessment.PrimaryDiagnosis)
+	writeList(&b, "Differentials", n.Assessment.Differentials)
+	writeList(&b, "Treatment", n.Plan.ImmediateTreatment)
+	medications := make([]string, len(n.Plan.Medications))
+	for i, m := range n.Plan.Medications {
+		medications[i] = m.String()
+	}
+	writeList(&b, "Medications", medications)
+	writeField(&b, "Follow-up", n.Plan.FollowUp)
+	return b.String()
+}
+
+func writeField(b *strings.Builder, label, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		fmt.Fprintf(b, "%s: %s\n", label, value)
+	}
+}
+
+func writeList(b *strings.Builder, label string, values []string) {
+	if len(values) > 0 {
+		fmt.Fprintf(b, "%s: %s\n", label, strings.Join(values, "; "))
+	}
+}
+
+func historyFingerprint(history string) string {
+	sum := sha256.Sum256([]byte(history))
+	return hex.EncodeToString(sum[:])
+}
+
+// withAllergyAlerts makes sure every recorded allergy appears among the
+// alerts, whatever the model chose to mention.
+func withAllergyAlerts(alerts []string, allergies []models.PatientAllergy) []string {
+	for _, a := range allergies {
+		mentioned := false
+		for _, alert := range alerts {
+			if strings.Contains(strings.ToLower(alert), strings.ToLower(a.Name())) {
+				mentioned = true
+				break
+			}
+		}
+		if !mentioned {
+			alert := "Allergy: " + a.Name()
+			if a.Severity != "" {
+				alert += " (" + a.Severity + ")"
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// historyBudget is the number of tokens available for the history in the
+// summary prompt.
+func (s *PatientSummaryService) historyBudget() int {
+	return max(s.soap.ContextTokens()-summaryPromptTokens-summaryResponseTokens, 512)
+}
+
+// condensedHistory builds a history that fits the model's context for
+// patients whose full record does not. Recent notes are kept verbatim in up to
+// half the budget; older notes are replaced by stored per-visit summaries,
+// rolled up into stored yearly digests for completed years.
+func (s *PatientSummaryService) condensedHistory(patient *models.Patient, notes []models.Note) (string, error) {
+	header := formatPatientHeader(patient)
+	remaining := s.historyBudget() - EstimateTokens(header)
+
+	split, used := len(notes), 0
+	for split > 0 {
+		tokens := EstimateTokens(FormatNote(&notes[split-1]))
+		if used+tokens > remaining/2 {
+			break
+		}
+		used += tokens
+		split--
+	}
+	older, recent := notes[:split], notes[split:]
+
+	summaries, err := s.visitSummaries(patient.ID, older)
+	if err != nil {
+		return "", err
+	}
+
+	// Completed years are rolled up into digests; the current year keeps its
+	// visit summaries.
+	currentYear := time.Now().Year()
+	var sections []string
+	for i := 0; i < len(summaries); {
+		year := summaries[i].VisitDate.Year()
+		j := i
+		var entries []string
+		for ; j < len(summaries) && summaries[j].VisitDate.Year() == year; j++ {
+			entries = append(entries, visitSummaryLine(&summaries[j]))
+		}
+		if year < currentYear {
+			digest, err := s.yearlyDigest(patient.ID, year, entries)
+			if err != nil {
+				return "", err
+			}
+			sections = append(sections, fmt.Sprintf("%d (%d visits): %s", year, digest.VisitCount, digest.Summary))
+		} else {
+			sections = append(sections, entries...)
+		}
+		i = j
+	}
+
+	earlier := strings.Join(sections, "\n")
+	if EstimateTokens(earlier) > remaining-used {
+		earlier, err = s.condense("earlier years", sections)
+		if err != nil {
+			return "", err
+		}
+		earlier = truncateTokens(earlier, remaining-used)
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	if earlier != "" {
+		b.WriteString("\nEarlier history (condensed):\n")
+		b.WriteString(earlier)
+		b.WriteString("\n")
+	}
+	b.WriteString("\nRecent visits:\n")
+	if len(recent) == 0 {
+		b.WriteString("- see earlier history\n")
+	}
+	for _, n := range recent {
+		b.WriteString(FormatNote(&n))
+	}
+	return b.String(), nil
+}
+
+// visitSummaries returns a summary for each note, generating and storing the
+// ones that are missing or out of date.
+func (s *PatientSummaryService) visitSummaries(patientID uint, notes []models.Note) ([]models.VisitSummary, error) {
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(notes))
+	for i, n := range notes {
+		ids[i] = n.ID
+	}
+	var stored []models.VisitSummary
+	if err := s.db.Where("note_id IN ?", ids).Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("error loading visit summaries: %v", err)
+	}
+	byNote := make(map[uint]models.VisitSummary, len(stored))
+	for _, v := range stored {
+		byNote[v.NoteID] = v
+	}
+
+	summaries := make([]models.VisitSummary, len(notes))
+	for i := range notes {
+		visit := FormatNote(&notes[i])
+		fingerprint := historyFingerprint(visit)
+		existing, ok := byNote[notes[i].ID]
+		if ok && existing.Fingerprint == fingerprint {
+			summaries[i] = existing
+			continue
+		}
+
+		text, err := s.soap.SummarizeVisit(truncateTokens(visit, s.historyBudget()))
+		if err != nil {
+			return nil, err
+		}
+		existing.PatientID = patientID
+		existing.NoteID = notes[i].ID
+		existing.VisitDate = notes[i].CreatedAt
+		existing.Summary = text
+		existing.Fingerprint = fingerprint
+		if err := s.db.Save(&existing).Error; err != nil {
+			return nil, fmt.Errorf("error saving visit summary: %v", err)
+		}
+		summaries[i] = existing
+	}
+	return summaries, nil
+}
+
+// yearlyDigest returns the stored digest for a year, regenerating it when the
+// visit summaries it was built from have changed.
+func (s *PatientSummaryService) yearlyDigest(patientID uint, year int, entries []string) (*models.HistoryDigest, error) {
+	fingerprint := historyFingerprint(strings.Join(entries, "\n"))
+
+	var digest models.HistoryDigest
+	err := s.db.Where("patient_id = ? AND year = ?", patientID, year).First(&digest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("error loading history digest: %v", err)
+	}
+	if err == nil && digest.Fingerprint == fingerprint {
+		return &digest, nil
+	}
+
+	text, err := s.condense(fmt.Sprintf("%d", year), entries)
+	if err != nil {
+		return nil, err
+	}
+	digest.PatientID = patientID
+	digest.Year = year
+	digest.VisitCount = len(entries)
+	digest.Summary = text
+	digest.Fingerprint = fingerprint
+	if err := s.db.Save(&digest).Error; err != nil {
+		return nil, fmt.Errorf("error saving history digest: %v", err)
+	}
+	return &digest, nil
+}
+
+// condense reduces entries to a single digest. Entries are condensed in
+// batches that fit the model's context, then the batch digests are condensed
+// again until one remains.
+func (s *PatientSummaryService) condense(period string, entries []string) (string, error) {
+	budget := s.historyBudget()
+	for {
+		var batches [][]string
+		var batch []string
+		used := 0
+		for _, e := range entries {
+			e = truncateTokens(e, budget)
+			tokens := EstimateTokens(e) + 1
+			if len(batch) > 0 && used+tokens > budget {
+				batches = append(batches, batch)
+				batch, used = nil, 0
+			}
+			batch = append(batch, e)
+			used += tokens
+		}
+		if len(batch) > 0 {
+			batches = append(batches, batch)
+		}
+
+		condensed := make([]string, 0, len(batches))
+		for _, b := range batches {
+			text, err := s.soap.CondenseHistory(period, b)
+			if err != nil {
+				return "", err
+			}
+			condensed = append(condensed, text)
+		}
+		if len(condensed) <= 1 {
+			return strings.Join(condensed, ""), nil
+		}
+		if len(condensed) >= len(entries) {
+			// The model is not shortening its input; stop rather than loop.
+			return strings.Join(condensed, "\n"), nil
+		}
+		entries = condensed
+	}
+}
+
+func visitSummaryLine(v *models.VisitSummary) string {
+	return fmt.Sprintf("%s: %s", v.VisitDate.Format("2006-01-02"), v.Summary)
+}
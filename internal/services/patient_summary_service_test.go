@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"vet-tails/ai/internal/models"
+)
+
+func TestActiveOrders(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	note := func(id uint, daysAgo int, orders ...models.MedicationOrder) models.Note {
+		n := models.Note{ID: id, CreatedAt: now.AddDate(0, 0, -daysAgo)}
+		n.Plan.Medications = orders
+		return n
+	}
+	tests := []struct {
+		name   string
+		notes  []models.Note
+		except uint
+		want   []string
+	}{
+		{"open-ended order is ongoing", []models.Note{note(1, 90, models.MedicationOrder{Drug: "levothyroxine"})}, 0, []string{"levothyroxine"}},
+		{"finite course still running", []models.Note{note(1, 3, models.MedicationOrder{Drug: "amoxicillin", DurationDays: 7})}, 0, []string{"amoxicillin: for 7 days"}},
+		{"finite course ended", []models.Note{note(1, 10, models.MedicationOrder{Drug: "amoxicillin", DurationDays: 7})}, 0, nil},
+		{"excluded note", []models.Note{note(1, 1, models.MedicationOrder{Drug: "meloxicam"})}, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := activeOrders(tt.notes, tt.except, now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("activeOrders = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("activeOrders[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
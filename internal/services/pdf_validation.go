@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ledongthuc/pdf"
+)
+
+var (
+	ErrNotPDF       = errors.New("file is not a PDF")
+	ErrEncryptedPDF = errors.New("encrypted PDFs are not supported")
+	ErrMalformedPDF = errors.New("malformed PDF")
+)
+
+// ValidatePDF checks the magic bytes and document structure of a PDF on disk.
+// Failures wrap ErrNotPDF, ErrEncryptedPDF or ErrMalformedPDF.
+func ValidatePDF(path string) (err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening file: %v", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(f, header); err != nil || !bytes.Equal(header, []byte("%PDF-")) {
+		return ErrNotPDF
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	// The PDF reader panics on some malformed inputs.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrMalformedPDF, r)
+		}
+	}()
+
+	r, err := pdf.NewReader(f, info.Size())
+	if err != nil {
+		if bytes.Contains(readTail(f, info.Size()), []byte("/Encrypt")) {
+			return ErrEncryptedPDF
+		}
+		return fmt.Errorf("%w: %v", ErrMalformedPDF, err)
+	}
+	if !r.Trailer().Key("Encrypt").IsNull() {
+		return ErrEncryptedPDF
+	}
+	if r.NumPage() == 0 {
+		return fmt.Errorf("%w: no pages", ErrMalformedPDF)
+	}
+	return nil
+}
+
+// readTail returns the last few KB of the file, where the trailer lives.
+func readTail(f io.ReaderAt, size int64) []byte {
+	const tailSize = 4096
+	off := size - tailSize
+	if off < 0 {
+		off = 0
+	}
+	buf := make([]byte, size-off)
+	n, _ := f.ReadAt(buf, off)
+	return buf[:n]
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucsky/cuid"
+)
+
+const (
+	ReembedStatusRunning   = "running"
+	ReembedStatusCompleted = "completed"
+	ReembedStatusFailed    = "failed"
+)
+
+var (
+	// ErrCollectionBusy is returned when a collection cannot be written to or
+	// migrated because another operation on it is in progress.
+	ErrCollectionBusy = errors.New("collection is busy")
+	// ErrReembedUnchanged is returned when a collection is already embedded
+	// with the requested model.
+	ErrReembedUnchanged = errors.New("collection is already embedded with this model")
+)
+
+// reembedBatchSize is the number of stored chunks re-embedded per request.
+const reembedBatchSize = 100
+
+// ReembedJob tracks the migration of a collection to a new embedding model.
+type ReembedJob struct {
+	ID            string     `json:"id"`
+	Collection    string     `json:"collection"`
+	FromModel     string     `json:"from_model"`
+	FromDimension int        `json:"from_dimension"`
+	ToModel       string     `json:"to_model"`
+	ToDimension   int        `json:"to_dimension"`
+	Status        string     `json:"status"`
+	Total         int        `json:"total"`
+	Processed     int        `json:"processed"`
+	Error         string     `json:"error,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// reembedJobs tracks re-embed jobs and the collections they lock. While a
+// collection is being migrated nothing else may write to it, since chunks
+// added to the original after they were copied would be lost in the swap.
+type reembedJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*ReembedJob
+	// running maps a collection to the ID of the job migrating it.
+	running map[string]string
+	// writers counts the writes in progress per collection.
+	writers map[string]int
+}
+
+func newReembedJobs() *reembedJobs {
+	return &reembedJobs{
+		jobs:    make(map[string]*ReembedJob),
+		running: make(map[string]string),
+		writers: make(map[string]int),
+	}
+}
+
+// start registers job and locks its collection against writes.
+func (j *reembedJobs) start(job *ReembedJob) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if id, ok := j.running[job.Collection]; ok {
+		return fmt.Errorf("%w: re-embed job %s is already running on %s", ErrCollectionBusy, id, job.Collection)
+	}
+	if j.writers[job.Collection] > 0 {
+		return fmt.Errorf("%w: documents are being ingested into %s", ErrCollectionBusy, job.Collection)
+	}
+	j.jobs[job.ID] = job
+	j.running[job.Collection] = job.ID
+	return nil
+}
+
+// finish releases the collection locked by a job.
+func (j *reembedJobs) finish(job *ReembedJob) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.running[job.Collection] == job.ID {
+		delete(j.running, job.Collection)
+	}
+}
+
+// beginWrite registers a write to collection, refusing it while the
+// collection is being migrated. The returned function ends the write.
+func (j *reembedJobs) beginWrite(collection string) (func(), error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if id, ok := j.running[collection]; ok {
+		return nil, fmt.Errorf("%w: re-embed job %s is running on %s", ErrCollectionBusy, id, collection)
+	}
+	j.writers[collection]++
+	return func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if j.writers[collection]--; j.writers[collection] <= 0 {
+			delete(j.writers, collection)
+		}
+	}, nil
+}
+
+func (j *reembedJobs) update(id string, fn func(job *ReembedJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// ReembedJob returns a snapshot of a re-embed job.
+func (s *KnowledgeBaseService) ReembedJob(id string) (*ReembedJob, bool) {
+	s.jobs.mu.Lock()
+	defer s.jobs.mu.Unlock()
+	job, ok := s.jobs.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *job
+	return &snapshot, true
+}
+
+// StartReembed launches a background job that migrates a collection to the
+// target embedder. The stored chunk texts are re-embedded, so the source files
+// are not needed. Only one job may run per collection, and the collection
+// refuses writes until the job finishes.
+func (s *KnowledgeBaseService) StartReembed(collectionName string, target Embedder) (*ReembedJob, error) {
+	collection, err := s.store.GetCollection(context.Background(), collectionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %v", err)
+	}
+	fromModel, fromDimension := collectionEmbedder(collection.Metadata)
+	if fromModel == target.Model() && fromDimension == target.Dimension() {
+		return nil, fmt.Errorf("%w: %s uses %s (%d dimensions)", ErrReembedUnchanged, collectionName, fromModel, fromDimension)
+	}
+
+	job := &ReembedJob{
+		ID:            cuid.New(),
+		Collection:    collectionName,
+		FromModel:     fromModel,
+		FromDimension: fromDimension,
+		ToModel:       target.Model(),
+		ToDimension:   target.Dimension(),
+		Status:        ReembedStatusRunning,
+		StartedAt:     time.Now(),
+	}
+	if err := s.jobs.start(job); err != nil {
+		return nil, err
+	}
+	snapshot := *job
+
+	go func() {
+		err := s.reembedCollection(context.Background(), collectionName, target, func(processed, total int) {
+			s.jobs.update(job.ID, func(j *ReembedJob) {
+				j.Processed = processed
+				j.Total = total
+			})
+		})
+		s.jobs.finish(job)
+		s.jobs.update(job.ID, func(j *ReembedJob) {
+			now := time.Now()
+			j.FinishedAt = &now
+			if err != nil {
+				j.Status = ReembedStatusFailed
+				j.Error = err.Error()
+				log.Printf("❌ Re-embedding %s failed: %v\n", collectionName, err)
+				return
+			}
+			j.Status = ReembedStatusCompleted
+			log.Printf("✅ Re-embedded %s with %s", collectionName, target.Model())
+		})
+	}()
+
+	return &snapshot, nil
+}
+
+// reembedCollection copies every chunk of a collection into a staging
+// collection embedded with target, then swaps the staging collection in under
+// the original name. The original collection is left untouched on failure.
+// Callers must keep the collection from being written to meanwhile.
+func (s *KnowledgeBaseService) reembedCollection(ctx context.Context, collectionName string, target Embedder, progress func(processed, total int)) error {
+	src, err := s.store.GetCollection(ctx, collectionName)
+	if err != nil {
+		return fmt.Errorf("failed to get collection: %v", err)
+	}
+	total, err := s.store.Count(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+
+	metadata := mutableMetadata(src.Metadata)
+	for k, v := range embedderMetadata(target) {
+		metadata[k] = v
+	}
+
+	stagingName := collectionName + "__reembed"
+	s.store.DeleteCollection(ctx, stagingName)
+	if _, err := s.store.CreateCollection(ctx, stagingName, metadata); err != nil {
+		return fmt.Errorf("failed to create staging collection: %v", err)
+	}
+
+	processed := 0
+	for offset := 0; offset < total; offset += reembedBatchSize {
+		page, err := s.store.Get(ctx, collectionName, nil, reembedBatchSize, offset)
+		if err != nil {
+			s.store.DeleteCollection(ctx, stagingName)
+			return fmt.Errorf("failed to read chunks: %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		if err := s.copyReembedded(ctx, stagingName, page, target); err != nil {
+			s.store.DeleteCollection(ctx, stagingName)
+			return err
+		}
+		processed += len(page)
+		if progress != nil {
+			progress(processed, total)
+		}
+	}
+
+	// Swap the staging collection in under the original name.
+	previousName := collectionName + "__previous"
+	s.store.DeleteCollection(ctx, previousName)
+	if err := s.store.UpdateCollection(ctx, collectionName, previousName, src.Metadata); err != nil {
+		s.store.DeleteCollection(ctx, stagingName)
+		return fmt.Errorf("failed to rename original collection: %v", err)
+	}
+	if err := s.store.UpdateCollection(ctx, stagingName, collectionName, metadata); err != nil {
+		s.store.UpdateCollection(ctx, previousName, collectionName, src.Metadata)
+		return fmt.Errorf("failed to rename staging collection: %v", err)
+	}
+	if err := s.store.DeleteCollection(ctx, previousName); err != nil {
+		log.Printf("⚠️ Failed to delete %s: %v\n", previousName, err)
+	}
+	return nil
+}
+
+func (s *KnowledgeBaseService) copyReembedded(ctx context.Context, collection string, page []VectorRecord, target Embedder) error {
+	texts := make([]string, len(page))
+	for i, r := range page {
+		texts[i] = r.Document
+	}
+	vectors, err := target.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return err
+	}
+	for i := range page {
+		page[i].Embedding = vectors[i]
+	}
+	if err := s.store.Add(ctx, collection, page); err != nil {
+		return fmt.Errorf("failed to add re-embedded chunks: %v", err)
+	}
+	return nil
+}
+
+// mutableMetadata copies collection metadata without the index settings,
+// which Chroma refuses to modify after creation.
+func mutableMetadata(metadata map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		if !strings.HasPrefix(k, "hnsw:") {
+			out[k] = v
+		}
+	}
+	return out
+}
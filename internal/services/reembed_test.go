@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// gatedEmbedder is a target embedder that waits for release before embedding.
+type gatedEmbedder struct {
+	stubEmbedder
+	release chan struct{}
+}
+
+func (e *gatedEmbedder) Model() string { return "gated" }
+
+func (e *gatedEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	<-e.release
+	return e.stubEmbedder.EmbedDocuments(ctx, texts)
+}
+
+func TestStartReembed(t *testing.T) {
+	ctx := context.Background()
+	kb, embedder := newTestKnowledgeBase(t)
+	path := writeDoc(t, t.TempDir(), "a.txt", "Meloxicam is an NSAID.")
+	if err := kb.AddDocuments(ctx, "docs", path, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := kb.StartReembed("docs", embedder); !errors.Is(err, ErrReembedUnchanged) {
+		t.Fatalf("re-embed with the current model: err = %v, want ErrReembedUnchanged", err)
+	}
+
+	target := &gatedEmbedder{release: make(chan struct{})}
+	kb.newEmbedder = func(model string, dimension int) (Embedder, error) {
+		if model != target.Model() {
+			return nil, errors.New("unexpected model " + model)
+		}
+		return target, nil
+	}
+	job, err := kb.StartReembed("docs", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kb.StartReembed("docs", target); !errors.Is(err, ErrCollectionBusy) {
+		t.Errorf("second job: err = %v, want ErrCollectionBusy", err)
+	}
+	if err := kb.AddDocuments(ctx, "docs", path, "b.txt"); !errors.Is(err, ErrCollectionBusy) {
+		t.Errorf("ingest during job: err = %v, want ErrCollectionBusy", err)
+	}
+	close(target.release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		snapshot, _ := kb.ReembedJob(job.ID)
+		if snapshot.Status == ReembedStatusCompleted {
+			break
+		}
+		if snapshot.Status == ReembedStatusFailed || time.Now().After(deadline) {
+			t.Fatalf("job = %+v, want completed", snapshot)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	collection, err := kb.store.GetCollection(ctx, "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model, _ := collectionEmbedder(collection.Metadata); model != "gated" {
+		t.Errorf("collection model = %s, want gated", model)
+	}
+	if n, _ := kb.store.Count(ctx, "docs"); n != 1 {
+		t.Errorf("collection has %d chunks, want 1", n)
+	}
+	if _, err := kb.SearchKnowledgeBase(ctx, "docs", "meloxicam"); err != nil {
+		t.Errorf("query after job: %v", err)
+	}
+	if err := kb.AddDocuments(ctx, "docs", path, "b.txt"); err != nil {
+		t.Errorf("ingest after job: %v", err)
+	}
+}
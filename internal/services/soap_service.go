@@ -4,108 +4,264 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"vet-tails/ai/internal/formulary"
 	"vet-tails/ai/internal/models"
 )
 
 type SOAPService struct {
 	ollamaURL string
+	model     string
+	// contextTokens is the model's context window size.
+	contextTokens int
 }
 
 type OllamaRequest struct {
-	Model       string  `json:"model"`
-	Prompt      string  `json:"prompt"`
-	Temperature float32 `json:"temperature"`
-	Stream      bool    `json:"stream"`
+	Model       string                 `json:"model"`
+	Prompt      string                 `json:"prompt"`
+	Temperature float32                `json:"temperature"`
+	Stream      bool                   `json:"stream"`
+	Format      string                 `json:"format,omitempty"`
+	Options     map[string]interface{} `json:"options,omitempty"`
 }
 
 type OllamaResponse struct {
 	Response string `json:"response"`
 }
 
-func NewSOAPService(ollamaURL string) *SOAPService {
+// Defaults used when no text model is configured.
+const (
+	DefaultOllamaModel        = "mistral"
+	DefaultModelContextTokens = 8192
+)
+
+func NewSOAPService(ollamaURL string, model string, contextTokens int) *SOAPService {
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	if contextTokens <= 0 {
+		contextTokens = DefaultModelContextTokens
+	}
 	return &SOAPService{
-		ollamaURL: ollamaURL,
+		ollamaURL:     ollamaURL,
+		model:         model,
+		contextTokens: contextTokens,
 	}
 }
-func (s *SOAPService) GenerateSOAPNote(transcribedText string) (*models.Note, error) {
-	prompt := fmt.Sprintf(`As a veterinary AI assistant, analyze the following consultation transcript and generate a SOAP note:
 
-    Transcript:
-    %s
-
-    Generate a structured SOAP note with the following sections:
-    - Subjective (patient info, chief complaint, duration, history, symptoms)
-    - Objective (vital signs, examination findings)
-    - Assessment (primary diagnosis, differential diagnoses)
-    - Plan (immediate treatment, medications, follow-up, client education)
+// ContextTokens returns the context window of the configured model.
+func (s *SOAPService) ContextTokens() int {
+	return s.contextTokens
+}
 
-    Format the response in a valid JSON structure matching this example:
-    {
-        "subjective": {
+// soapSectionExamples show the model the JSON shape of each SOAP section.
+var soapSectionExamples = map[string]string{
+	models.SectionSubjective: `{
             "patient_info": "species, age, sex",
             "chief_complaint": "main issue",
             "duration": "time period",
             "history": "relevant history",
             "symptoms": ["symptom1", "symptom2"]
-        },
-        "objective": {
+        }`,
+	models.SectionObjective: `{
             "vital_signs": {
-                "temperature": "value",
+                "temperature": {"value": 38.6, "unit": "°C"},
+                "heart_rate": {"value": 110, "unit": "bpm"},
+                "respiratory_rate": {"value": 24, "unit": "breaths/min"},
+                "weight": {"value": 12.4, "unit": "kg"},
+                "mucous_membranes": "pink, moist",
+                "capillary_refill_time": {"value": 1.5, "unit": "s"},
+                "hydration": "normal",
+                "pain_score": {"value": 2, "unit": "/10"},
+                "body_condition_score": {"value": 5, "unit": "/9"},
                 "general_condition": "description"
-				"respiratory_rate": "value",
-				"weight": "value",
             },
             "examination_findings": ["finding1", "finding2"]
-        },
-        "assessment": {
+        }`,
+	models.SectionAssessment: `{
             "primary_diagnosis": "main diagnosis",
             "differentials": ["differential1", "differential2"]
-        },
-        "plan": {
+        }`,
+	models.SectionPlan: `{
             "immediate_treatment": ["treatment1", "treatment2"],
-            "medications": ["medication1", "medication2"],
+            "medications": [
+                {
+                    "drug": "generic drug name",
+                    "strength": "product strength, e.g. 250 mg",
+                    "dose": 12.5,
+                    "unit": "mg/kg",
+                    "route": "PO",
+                    "frequency": "BID",
+                    "duration_days": 10,
+                    "quantity": 20,
+                    "dispense_unit": "tablet",
+                    "instructions": "give with food"
+                }
+            ],
             "follow_up": "follow up plan",
             "client_education": ["education1", "education2"]
-        }
-    }`, transcribedText)
+        }`,
+}
 
-	reqBody := OllamaRequest{
-		Model:       "mistral",
-		Prompt:      prompt,
-		Temperature: 0.7,
-		Stream:      false,
+// soapNoteExample is the JSON shape of a whole SOAP note.
+func soapNoteExample() string {
+	parts := make([]string, len(models.SOAPSections))
+	for i, section := range models.SOAPSections {
+		parts[i] = fmt.Sprintf("        %q: %s", section, soapSectionExamples[section])
 	}
+	return "{\n" + strings.Join(parts, ",\n") + "\n    }"
+}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %v", err)
+// GenerateSOAPNote generates a SOAP note from a consultation transcript. When
+// the patient is known its record informs the prompt and the plan's
+// medications are checked against it; see CheckPlanSafety.
+func (s *SOAPService) GenerateSOAPNote(transcribedText string, patient *PatientContext) (*models.Note, error) {
+	prompt := fmt.Sprintf(`As a veterinary AI assistant, analyze the following consultation transcript and generate a SOAP note:
+%s
+    Transcript:
+    %s
+
+    Generate a structured SOAP note with the following sections:
+    - Subjective (patient info, chief complaint, duration, history, symptoms)
+    - Objective (vital signs, examination findings); use null for any vital sign value not stated in the transcript
+    - Assessment (primary diagnosis, differential diagnoses)
+    - Plan (immediate treatment, medications, follow-up, client education)
+
+    Format the response in a valid JSON structure matching this example:
+    %s`, patientContextPrompt(patient), transcribedText, soapNoteExample())
+
+	var note models.Note
+	if err := s.generate(prompt, 0.7, &note); err != nil {
+		return nil, fmt.Errorf("error parsing SOAP note: %v", err)
 	}
+	note.Transcript = transcribedText
+	prepareNote(&note, patient)
 
-	resp, err := http.Post(
-		fmt.Sprintf("%s/api/generate", s.ollamaURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	return &note, nil
+}
+
+// prepareNote normalizes the note's medications and vital signs, flags
+// abnormal vitals, checks the plan's safety and links items to their
+// evidence in the transcript. It runs on generated notes and again after
+// every edit.
+func prepareNote(note *models.Note, patient *PatientContext) {
+	note.NormalizeMedications()
+	note.Objective.VitalSigns.Normalize()
+	note.Objective.VitalSigns.Evaluate(noteSpecies(note, patient))
+	// A weight measured during the consultation is the best basis for dose
+	// checks when none was given.
+	if weight := note.Objective.VitalSigns.Weight.Value; patient != nil && patient.WeightKg == 0 && weight != nil {
+		patient.WeightKg = *weight
+	}
+	CheckPlanSafety(note, patient)
+	note.LinkEvidence()
+}
+
+// sectionGuidance is what each section should contain, as in the full note
+// prompt.
+var sectionGuidance = map[string]string{
+	models.SectionSubjective: "patient info, chief complaint, duration, history, symptoms",
+	models.SectionObjective:  "vital signs, examination findings; use null for any vital sign value not stated in the transcript",
+	models.SectionAssessment: "primary diagnosis, differential diagnoses",
+	models.SectionPlan:       "immediate treatment, medications, follow-up, client education",
+}
+
+// RegenerateSection rewrites one section of a note from its transcript. The
+// other sections, which the vet may have edited, are given as fixed context
+// along with the vet's instructions. It returns the new section as JSON.
+func (s *SOAPService) RegenerateSection(note *models.Note, section string, instructions string, patient *PatientContext) (json.RawMessage, error) {
+	example, ok := soapSectionExamples[section]
+	if !ok {
+		return nil, fmt.Errorf("unknown SOAP section %q", section)
+	}
+	sections := map[string]interface{}{}
+	data, err := json.Marshal(note.Content())
 	if err != nil {
-		return nil, fmt.Errorf("error calling Ollama API: %v", err)
+		return nil, fmt.Errorf("error encoding note: %v", err)
+	}
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("error encoding note: %v", err)
+	}
+	current := sections[section]
+	delete(sections, section)
+	fixed, _ := json.MarshalIndent(sections, "    ", "  ")
+	previous, _ := json.MarshalIndent(current, "    ", "  ")
+
+	var guidance string
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		guidance = fmt.Sprintf("\n    The veterinarian's instructions for this section:\n    %s\n", instructions)
 	}
-	defer resp.Body.Close()
 
-	var result OllamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	prompt := fmt.Sprintf(`As a veterinary AI assistant, rewrite the %[1]s section of a SOAP note from the following consultation transcript.
+%[2]s
+    Transcript:
+    %[3]s
+
+    The other sections have been reviewed by the veterinarian. Treat them as correct and keep the %[1]s consistent with them:
+    %[4]s
+
+    The previous %[1]s section, which is being replaced:
+    %[5]s
+%[6]s
+    The %[1]s section contains: %[7]s.
+    Only include what the transcript and the other sections support.
+
+    Format the response in a valid JSON structure matching this example:
+    {"%[1]s": %[8]s}`, section, patientContextPrompt(patient), note.Transcript, fixed, previous, guidance, sectionGuidance[section], example)
+
+	var out map[string]json.RawMessage
+	if err := s.generate(prompt, 0.7, &out); err != nil {
+		return nil, fmt.Errorf("error parsing %s section: %v", section, err)
+	}
+	if raw, ok := out[section]; ok {
+		return raw, nil
 	}
+	// Some models answer with the section's fields directly.
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s section: %v", section, err)
+	}
+	return raw, nil
+}
 
-	// Parse the JSON response into Note structure
-	var note models.Note
-	if err := json.Unmarshal([]byte(result.Response), &note); err != nil {
-		// Log the response for debugging
-		fmt.Printf("Raw response: %s\n", result.Response)
-		return nil, fmt.Errorf("error parsing SOAP note: %v", err)
+// noteSpecies returns the patient's recorded species, or the one named in
+// the note's patient info.
+func noteSpecies(note *models.Note, patient *PatientContext) string {
+	if patient != nil && patient.Patient != nil && patient.Patient.Species != "" {
+		return patient.Patient.Species
 	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(note.Subjective.PatientInfo), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if species := formulary.NormalizeSpecies(word); species == formulary.SpeciesDog || species == formulary.SpeciesCat {
+			return species
+		}
+	}
+	return ""
+}
 
-	return &note, nil
+// patientContextPrompt renders the patient record for the SOAP prompt, so
+// doses are written for the right species and weight and allergies are
+// avoided.
+func patientContextPrompt(patient *PatientContext) string {
+	if patient == nil || patient.Patient == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n    Patient record:\n")
+	for _, line := range strings.Split(strings.TrimSpace(formatPatientHeader(patient.Patient)), "\n") {
+		if line != "" {
+			b.WriteString("    " + line + "\n")
+		}
+	}
+	if patient.WeightKg > 0 {
+		fmt.Fprintf(&b, "    Current weight: %g kg\n", patient.WeightKg)
+	}
+	return b.String()
 }
 
 // ... existing code ...
@@ -146,51 +302,27 @@ func (s *SOAPService) GeneratePatientSummary(patientHistory string) (*models.Pat
         }
     }`, patientHistory)
 
-	reqBody := OllamaRequest{
-		Model:       "mistral",
-		Prompt:      prompt,
-		Temperature: 0.7,
-		Stream:      false,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %v", err)
-	}
-
-	resp, err := http.Post(
-		fmt.Sprintf("%s/api/generate", s.ollamaURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("error calling Ollama API: %v", err)
-	}
-	defer resp.Body.Close()
-
-	var result OllamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
-	}
-
 	var summary models.PatientSummary
-	if err := json.Unmarshal([]byte(result.Response), &summary); err != nil {
-		fmt.Printf("Raw response: %s\n", result.Response)
+	if err := s.generate(prompt, 0.7, &summary); err != nil {
 		return nil, fmt.Errorf("error parsing patient summary: %v", err)
 	}
 
 	return &summary, nil
 }
 
-func (s *SOAPService) GeneratePetActivityLog(activityDescription string) (*models.ActivityLog, error) {
+// GeneratePetActivityLog structures an owner's activity report. submittedAt
+// anchors relative times such as "this morning".
+func (s *SOAPService) GeneratePetActivityLog(activityDescription string, submittedAt time.Time) (*models.ActivityLog, error) {
 	prompt := fmt.Sprintf(`As a veterinary AI assistant, analyze the following pet activity description and generate a structured activity log:
 
+    Reported at: %s
+
     Activity Description:
     %s
 
     Generate a detailed activity log with the following information:
     - Activity type (exercise, feeding, medication, grooming, behavior, etc.)
-    - Duration and timing
+    - Duration and timing (resolve relative times like "this morning" against the report time)
     - Observations and notes
     - Any concerns or follow-up needed
 
@@ -208,18 +340,32 @@ func (s *SOAPService) GeneratePetActivityLog(activityDescription string) (*model
         "concerns": ["concern1", "concern2"],
         "follow_up_needed": false,
         "notes": "additional relevant information"
-    }`, activityDescription)
+    }`, submittedAt.Format("2006-01-02 15:04 (Monday)"), activityDescription)
 
+	var activityLog models.ActivityLog
+	if err := s.generate(prompt, 0.7, &activityLog); err != nil {
+		return nil, fmt.Errorf("error parsing activity log: %v", err)
+	}
+	activityLog.Normalize(submittedAt)
+
+	return &activityLog, nil
+}
+
+// generate sends a prompt to the text model and decodes its JSON answer into
+// out. The context window is sized to the configured model.
+func (s *SOAPService) generate(prompt string, temperature float32, out interface{}) error {
 	reqBody := OllamaRequest{
-		Model:       "mistral",
+		Model:       s.model,
 		Prompt:      prompt,
-		Temperature: 0.7,
+		Temperature: temperature,
 		Stream:      false,
+		Format:      "json",
+		Options:     map[string]interface{}{"num_ctx": s.contextTokens},
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %v", err)
+		return fmt.Errorf("error marshaling request: %v", err)
 	}
 
 	resp, err := http.Post(
@@ -228,20 +374,101 @@ func (s *SOAPService) GeneratePetActivityLog(activityDescription string) (*model
 		bytes.NewBuffer(jsonData),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("error calling Ollama API: %v", err)
+		return fmt.Errorf("error calling Ollama API: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Ollama API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
 	var result OllamaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+		return fmt.Errorf("error decoding response: %v", err)
 	}
 
-	var activityLog models.ActivityLog
-	if err := json.Unmarshal([]byte(result.Response), &activityLog); err != nil {
+	if err := parseJSONResponse(result.Response, out); err != nil {
+		// Log the response for debugging
 		fmt.Printf("Raw response: %s\n", result.Response)
-		return nil, fmt.Errorf("error parsing activity log: %v", err)
+		return err
 	}
+	return nil
+}
 
-	return &activityLog, nil
+type textSummary struct {
+	Summary string `json:"summary"`
+}
+
+// SummarizeVisit condenses one visit into a few sentences for the patient's
+// long-term history.
+func (s *SOAPService) SummarizeVisit(visit string) (string, error) {
+	prompt := fmt.Sprintf(`As a veterinary AI assistant, summarize the following visit in at most three sentences for a patient's long-term history:
+
+    %s
+
+    Keep diagnoses, chronic conditions, medications with doses, adverse reactions, procedures and vaccinations. Drop routine normal findings.
+
+    Format the response in a valid JSON structure matching this example:
+    {
+        "summary": "short summary"
+    }`, visit)
+
+	var result textSummary
+	if err := s.generate(prompt, 0.3, &result); err != nil {
+		return "", fmt.Errorf("error parsing visit summary: %v", err)
+	}
+	return strings.TrimSpace(result.Summary), nil
+}
+
+// CondenseHistory merges visit summaries or earlier digests covering period
+// into a single digest.
+func (s *SOAPService) CondenseHistory(period string, entries []string) (string, error) {
+	prompt := fmt.Sprintf(`As a veterinary AI assistant, condense the following entries from a patient's medical history (%s) into one paragraph:
+
+    %s
+
+    Keep diagnoses, chronic conditions, medications with doses, adverse reactions, procedures, vaccinations and any trend over time. Drop details that are no longer clinically relevant.
+
+    Format the response in a valid JSON structure matching this example:
+    {
+        "summary": "condensed history"
+    }`, period, strings.Join(entries, "\n    "))
+
+	var result textSummary
+	if err := s.generate(prompt, 0.3, &result); err != nil {
+		return "", fmt.Errorf("error parsing history digest: %v", err)
+	}
+	return strings.TrimSpace(result.Summary), nil
+}
+
+// TriageConcerns rates how urgently a clinic should follow up on the concerns
+// in an activity log. patient describes the animal (species, breed, age).
+func (s *SOAPService) TriageConcerns(activity *models.ActivityLog, patient string) (*models.ConcernTriage, error) {
+	prompt := fmt.Sprintf(`As a veterinary triage assistant, rate how urgently the clinic should follow up on this owner report:
+
+    Patient: %s
+
+    Owner report:
+    %s
+
+    Activity type: %s
+    Observations: %s
+    Concerns: %s
+
+    Use "urgent" for possible emergencies (breathing difficulty, collapse, seizures, suspected toxin ingestion, bloat, inability to urinate, heavy bleeding), "high" for problems that should be seen within a day, "medium" for problems that need a call or appointment within a few days and "low" for routine questions.
+
+    Format the response in a valid JSON structure matching this example:
+    {
+        "severity": "medium",
+        "reasoning": "why this severity",
+        "recommended_action": "what the clinic should do"
+    }`, patient, activity.Description, activity.ActivityType, strings.Join(activity.Observations, "; "), strings.Join(activity.Concerns, "; "))
+
+	var triage models.ConcernTriage
+	if err := s.generate(prompt, 0.2, &triage); err != nil {
+		return nil, fmt.Errorf("error parsing concern triage: %v", err)
+	}
+	triage.Severity = models.NormalizeSeverity(triage.Severity)
+	return &triage, nil
 }
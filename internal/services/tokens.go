@@ -0,0 +1,23 @@
+package services
+
+import "unicode/utf8"
+
+// charsPerToken approximates how much English text one token covers for
+// llama-family tokenizers. Clinical vocabulary tokenizes worse than prose, so
+// the estimate is kept on the cautious side.
+const charsPerToken = 3
+
+// EstimateTokens approximates the number of tokens text will use.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// truncateTokens cuts text to roughly the given number of tokens.
+func truncateTokens(text string, tokens int) string {
+	limit := tokens * charsPerToken
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:limit])
+}
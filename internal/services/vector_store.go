@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Supported values for the VECTOR_STORE setting.
+const (
+	VectorStoreChroma = "chroma"
+	VectorStoreLocal  = "local"
+)
+
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// VectorRecord is one stored chunk with its embedding and metadata.
+type VectorRecord struct {
+	ID        string                 `json:"id"`
+	Document  string                 `json:"document"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	Embedding []float32              `json:"embedding,omitempty"`
+}
+
+// VectorMatch is a query result; Distance is the squared L2 distance to the
+// query embedding, smaller is closer.
+type VectorMatch struct {
+	VectorRecord
+	Distance float32 `json:"distance"`
+}
+
+// VectorStore persists embedded chunks grouped into named collections.
+//
+// Where filters follow Chroma's metadata filter syntax: {"key": value} for
+// equality, operator maps such as {"key": {"$gte": 3}} ($eq, $ne, $gt, $gte,
+// $lt, $lte, $in, $nin) and {"$and": [...]} / {"$or": [...]} combinators.
+type VectorStore interface {
+	// CreateCollection returns the named collection, creating it with the
+	// given metadata if it does not exist.
+	CreateCollection(ctx context.Context, name string, metadata map[string]interface{}) (*Collection, error)
+	GetCollection(ctx context.Context, name string) (*Collection, error)
+	DeleteCollection(ctx context.Context, name string) error
+	// UpdateCollection renames a collection and replaces its metadata.
+	UpdateCollection(ctx context.Context, name string, newName string, metadata map[string]interface{}) error
+	Count(ctx context.Context, collection string) (int, error)
+
+	Add(ctx context.Context, collection string, records []VectorRecord) error
+	// Get returns records matching where, ordered by insertion. A limit of
+	// zero returns all matches.
+	Get(ctx context.Context, collection string, where map[string]interface{}, limit int, offset int) ([]VectorRecord, error)
+	Delete(ctx context.Context, collection string, ids []string, where map[string]interface{}) error
+	Query(ctx context.Context, collection string, embedding []float32, nResults int, where map[string]interface{}) ([]VectorMatch, error)
+}
+
+// NewVectorStore builds the backend selected by kind.
+func NewVectorStore(kind string, chromaURL string, localDir string) (VectorStore, error) {
+	switch kind {
+	case "", VectorStoreChroma:
+		return NewChromaStore(chromaURL)
+	case VectorStoreLocal:
+		return NewLocalStore(localDir)
+	}
+	return nil, fmt.Errorf("unknown vector store %q", kind)
+}
+
+// matchWhere evaluates a Chroma-style metadata filter against metadata.
+func matchWhere(metadata map[string]interface{}, where map[string]interface{}) (bool, error) {
+	for key, cond := range where {
+		switch key {
+		case "$and", "$or":
+			clauses, ok := cond.([]interface{})
+			if !ok {
+				if typed, ok := cond.([]map[string]interface{}); ok {
+					for _, c := range typed {
+						clauses = append(clauses, c)
+					}
+				} else {
+					return false, fmt.Errorf("%s expects a list of filters", key)
+				}
+			}
+			matched := false
+			for _, clause := range clauses {
+				sub, ok := clause.(map[string]interface{})
+				if !ok {
+					return false, fmt.Errorf("%s expects a list of filters", key)
+				}
+				m, err := matchWhere(metadata, sub)
+				if err != nil {
+					return false, err
+				}
+				if key == "$and" && !m {
+					return false, nil
+				}
+				matched = matched || m
+			}
+			if key == "$or" && !matched {
+				return false, nil
+			}
+		default:
+			m, err := matchCondition(metadata[key], cond)
+			if err != nil {
+				return false, err
+			}
+			if !m {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func matchCondition(value interface{}, cond interface{}) (bool, error) {
+	ops, ok := cond.(map[string]interface{})
+	if !ok {
+		return metadataEqual(value, cond), nil
+	}
+	for op, operand := range ops {
+		var m bool
+		switch op {
+		case "$eq":
+			m = metadataEqual(value, operand)
+		case "$ne":
+			m = !metadataEqual(value, operand)
+		case "$gt", "$gte", "$lt", "$lte":
+			a, aok := metadataNumber(value)
+			b, bok := metadataNumber(operand)
+			if !aok || !bok {
+				return false, nil
+			}
+			switch op {
+			case "$gt":
+				m = a > b
+			case "$gte":
+				m = a >= b
+			case "$lt":
+				m = a < b
+			case "$lte":
+				m = a <= b
+			}
+		case "$in", "$nin":
+			list := reflect.ValueOf(operand)
+			if list.Kind() != reflect.Slice {
+				return false, fmt.Errorf("%s expects a list", op)
+			}
+			found := false
+			for i := 0; i < list.Len(); i++ {
+				if metadataEqual(value, list.Index(i).Interface()) {
+					found = true
+					break
+				}
+			}
+			m = found == (op == "$in")
+		default:
+			return false, fmt.Errorf("unsupported filter operator %s", op)
+		}
+		if !m {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// metadataEqual compares metadata values, treating all numeric types alike
+// since they round-trip through JSON as float64.
+func metadataEqual(a, b interface{}) bool {
+	if x, ok := metadataNumber(a); ok {
+		y, ok := metadataNumber(b)
+		return ok && x == y
+	}
+	if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		return ok && x == y
+	}
+	return a == b
+}
+
+func metadataNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func squaredL2(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}